@@ -0,0 +1,326 @@
+package log4go
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// In audit mode, each JSON entry ends with the fields
+//
+//	"seq":N,"hash":"H"
+//
+// where N counts the entries from 1, and H is the hex SHA-256, or
+// HMAC-SHA256 with AuditKey, of the hash of the previous entry, 32 zero
+// bytes for the first one, followed by the entry up to and including the
+// seq field. A checkpoint entry {"audit":"checkpoint","time":"T",...} is
+// added after every AuditCheckpointEvery entries, its hash signed with
+// AuditSigningKey in the "sig" field.
+
+const auditCheckpointMsg = "checkpoint"
+
+// auditSuffix matches the audit fields at the end of an entry.
+var auditSuffix = regexp.MustCompile(`,"seq":([0-9]+),"hash":"([0-9a-f]{64})"(?:,"sig":"([0-9a-f]+)")?}$`)
+
+func newAuditHash(key []byte) hash.Hash {
+	if len(key) > 0 {
+		return hmac.New(sha256.New, key)
+	}
+	return sha256.New()
+}
+
+// auditWriter chains the JSON entries written to w.
+type auditWriter struct {
+	w          *RotatingWriter
+	keys       KeyProvider
+	key        []byte
+	signer     ed25519.PrivateKey
+	every      int
+	recovering bool
+
+	mu      sync.Mutex
+	seq     uint64
+	prev    []byte
+	entries int // since the last checkpoint
+	buf     []byte
+}
+
+func newAuditWriter(w *RotatingWriter, opts Options) *auditWriter {
+	return &auditWriter{
+		w:          w,
+		keys:       opts.Encryption,
+		key:        opts.AuditKey,
+		signer:     opts.AuditSigningKey,
+		every:      opts.AuditCheckpointEvery,
+		recovering: true,
+		prev:       make([]byte, sha256.Size),
+	}
+}
+
+func (a *auditWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.recovering {
+		a.recover()
+		a.recovering = false
+	}
+	entry := bytes.TrimRight(p, "\r\n")
+	if len(entry) < 2 || entry[len(entry)-1] != '}' {
+		return 0, fmt.Errorf("log4go: audit entry isn't a JSON object: %q", entry)
+	}
+	if err := a.write(entry[:len(entry)-1], false); err != nil {
+		return 0, err
+	}
+	if a.entries++; a.every > 0 && a.entries >= a.every {
+		a.entries = 0
+		now := a.w.clock.Now().UTC().Format(time.RFC3339Nano)
+		cp := append([]byte(`{"audit":"`+auditCheckpointMsg+`","time":`), strconv.Quote(now)...)
+		if err := a.write(cp, true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// write chains the entry, less its closing brace.
+func (a *auditWriter) write(open []byte, checkpoint bool) error {
+	seq := a.seq + 1
+	a.buf = append(a.buf[:0], open...)
+	if open[len(open)-1] != '{' {
+		a.buf = append(a.buf, ',')
+	}
+	a.buf = append(a.buf, `"seq":`...)
+	a.buf = strconv.AppendUint(a.buf, seq, 10)
+	h := newAuditHash(a.key)
+	h.Write(a.prev)
+	h.Write(a.buf)
+	sum := h.Sum(nil)
+	a.buf = append(a.buf, `,"hash":"`...)
+	a.buf = append(a.buf, hex.EncodeToString(sum)...)
+	a.buf = append(a.buf, '"')
+	if checkpoint && a.signer != nil {
+		a.buf = append(a.buf, `,"sig":"`...)
+		a.buf = append(a.buf, hex.EncodeToString(ed25519.Sign(a.signer, sum))...)
+		a.buf = append(a.buf, '"')
+	}
+	a.buf = append(a.buf, "}\n"...)
+	if _, err := a.w.Write(a.buf); err != nil {
+		return err
+	}
+	a.seq, a.prev = seq, sum
+	return nil
+}
+
+// recover continues the chain of the last entry of the current file, or of
+// the newest rotated file, left by a previous process.
+func (a *auditWriter) recover() {
+	current := a.w.currentName(a.w.now())
+	names := []string{current}
+	if backups, err := a.w.backups(current); err == nil {
+		for _, b := range backups {
+			names = append(names, filepath.Join(filepath.Dir(current), b.Name()))
+		}
+	}
+	for _, name := range names {
+		f, err := a.w.fs.OpenFile(name, os.O_RDONLY, 0)
+		if err != nil {
+			continue
+		}
+		var last []string
+		r, err := openAuditLog(f, a.keys)
+		if err == nil {
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(nil, 64<<20)
+			for scanner.Scan() {
+				if m := auditSuffix.FindSubmatch(scanner.Bytes()); m != nil {
+					last = []string{string(m[1]), string(m[2])}
+				}
+			}
+		}
+		f.Close()
+		if last != nil {
+			a.seq, _ = strconv.ParseUint(last[0], 10, 64)
+			a.prev, _ = hex.DecodeString(last[1])
+			return
+		}
+	}
+}
+
+// openAuditLog returns the entries of the log r, decompressed if compressed
+// with gzip, and decrypted with keys if not nil.
+func openAuditLog(r io.Reader, keys KeyProvider) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = zr
+	} else {
+		r = br
+	}
+	if keys != nil {
+		r = NewDecryptReader(r, keys)
+	}
+	return r, nil
+}
+
+// AuditProblem is an inconsistency of an audit log found by AuditVerifier.
+type AuditProblem struct {
+	File   string
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (p AuditProblem) String() string {
+	return fmt.Sprintf("%s:%d: seq %d: %s", p.File, p.Line, p.Seq, p.Reason)
+}
+
+// AuditReport is the result of AuditVerifier.
+type AuditReport struct {
+	// Entries and Checkpoints count the entries verified.
+	Entries     int
+	Checkpoints int
+	// FirstSeq and LastSeq are the sequence numbers of the first and last
+	// entries.
+	FirstSeq uint64
+	LastSeq  uint64
+	// Unsigned counts the entries after the last signed checkpoint.
+	Unsigned int
+	Problems []AuditProblem
+}
+
+// OK reports whether no problem was found.
+func (r *AuditReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// AuditVerifier verifies the chain of the entries of audit logs.
+type AuditVerifier struct {
+	// Key is the AuditKey of the logger, if set.
+	Key []byte
+	// PublicKey verifies the signatures of the checkpoints, if not nil.
+	PublicKey ed25519.PublicKey
+	// Keys decrypts the logs written with Encryption.
+	Keys KeyProvider
+
+	report  AuditReport
+	started bool
+	prev    []byte
+}
+
+// VerifyFiles verifies the audit log files, oldest first, such as the
+// rotated files of a logger followed by its current file. The files
+// compressed with gzip are decompressed.
+func (v *AuditVerifier) VerifyFiles(names ...string) (*AuditReport, error) {
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return &v.report, err
+		}
+		_, err = v.Verify(name, f)
+		f.Close()
+		if err != nil {
+			return &v.report, err
+		}
+	}
+	return &v.report, nil
+}
+
+// Verify verifies the entries of the audit log r, named name in the
+// problems, continuing the chain of the logs verified before.
+func (v *AuditVerifier) Verify(name string, r io.Reader) (*AuditReport, error) {
+	r, err := openAuditLog(r, v.Keys)
+	if err != nil {
+		return &v.report, err
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		v.verifyLine(name, line, scanner.Bytes())
+	}
+	return &v.report, scanner.Err()
+}
+
+func (v *AuditVerifier) verifyLine(name string, line int, entry []byte) {
+	rep := &v.report
+	m := auditSuffix.FindSubmatchIndex(entry)
+	if m == nil {
+		rep.Problems = append(rep.Problems, AuditProblem{File: name, Line: line, Reason: "missing audit fields"})
+		return
+	}
+	seq, _ := strconv.ParseUint(string(entry[m[2]:m[3]]), 10, 64)
+	sum, _ := hex.DecodeString(string(entry[m[4]:m[5]]))
+	problem := func(reason string) {
+		rep.Problems = append(rep.Problems, AuditProblem{File: name, Line: line, Seq: seq, Reason: reason})
+	}
+
+	// the hash of the first entry is verified only if it starts the chain
+	prev := v.prev
+	switch {
+	case !v.started && seq != 1:
+		prev = nil
+	case !v.started:
+		prev = make([]byte, sha256.Size)
+	case seq == 1:
+		problem("chain restarted")
+		prev = make([]byte, sha256.Size)
+	case seq > rep.LastSeq+1:
+		problem(fmt.Sprintf("gap, expected seq %d", rep.LastSeq+1))
+		prev = nil
+	case seq <= rep.LastSeq:
+		problem(fmt.Sprintf("out of order after seq %d", rep.LastSeq))
+		prev = nil
+	}
+	// an entry out of order doesn't move the chain
+	advance := !v.started || seq == 1 || seq > rep.LastSeq
+	if prev != nil {
+		h := newAuditHash(v.Key)
+		h.Write(prev)
+		h.Write(entry[:m[3]])
+		if !hmac.Equal(h.Sum(nil), sum) {
+			problem("hash mismatch, entry altered")
+		}
+	}
+
+	checkpoint := bytes.HasPrefix(entry, []byte(`{"audit":"`+auditCheckpointMsg+`"`))
+	if checkpoint {
+		rep.Checkpoints++
+	} else {
+		rep.Entries++
+	}
+	rep.Unsigned++
+	if checkpoint && v.PublicKey != nil {
+		var sig []byte
+		if m[6] >= 0 {
+			sig, _ = hex.DecodeString(string(entry[m[6]:m[7]]))
+		}
+		if ed25519.Verify(v.PublicKey, sum, sig) {
+			rep.Unsigned = 0
+		} else {
+			problem("invalid checkpoint signature")
+		}
+	}
+	if !v.started {
+		rep.FirstSeq = seq
+		v.started = true
+	}
+	if advance {
+		rep.LastSeq = seq
+		v.prev = sum
+	}
+}
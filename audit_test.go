@@ -0,0 +1,91 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditChain(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "audit.log")
+	key := []byte("secret")
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	opts := []OptionHandler{WithFileName(name), WithAudit(key), WithAuditCheckpoint(2, priv), WithStack(false)}
+
+	flog := NewFileLogger(opts...)
+	flog.Info(context.TODO(), "one")
+	if err := flog.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	flog.Info(context.TODO(), "two")
+	flog.Close()
+	// a restarted logger continues the chain
+	flog = NewFileLogger(opts...)
+	flog.Info(context.TODO(), "three")
+	flog.Close()
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("got backups %v", backups)
+	}
+	files := append(backups, name)
+	v := &AuditVerifier{Key: key, PublicKey: pub}
+	rep, err := v.VerifyFiles(files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() || rep.Entries != 3 || rep.Checkpoints != 1 || rep.LastSeq != 4 || rep.Unsigned != 1 {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+
+	data, _ := os.ReadFile(name)
+	lines := strings.SplitAfter(string(data), "\n")
+	tests := []struct {
+		name   string
+		edit   func() string
+		reason string
+	}{
+		{"edit", func() string { return strings.Replace(string(data), "three", "THREE", 1) }, "hash mismatch"},
+		{"remove", func() string { return strings.Join(lines[1:], "") }, "gap"},
+		{"reorder", func() string { return lines[1] + lines[0] + strings.Join(lines[2:], "") }, "out of order"},
+	}
+	for _, tt := range tests {
+		v := &AuditVerifier{Key: key, PublicKey: pub}
+		if _, err := v.VerifyFiles(backups...); err != nil {
+			t.Fatal(err)
+		}
+		rep, _ := v.Verify(name, strings.NewReader(tt.edit()))
+		found := false
+		for _, p := range rep.Problems {
+			found = found || strings.Contains(p.Reason, tt.reason)
+		}
+		if !found {
+			t.Errorf("%s: unexpected problems %v", tt.name, rep.Problems)
+		}
+	}
+
+	// without the HMAC key, the hashes don't match
+	v = &AuditVerifier{PublicKey: pub}
+	if rep, _ := v.Verify(name, bytes.NewReader(data)); rep.OK() {
+		t.Error("verified without the key")
+	}
+}
+
+func TestAuditCheckpointClock(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	flog := NewFileLogger(WithFileName("/logs/audit.log"), WithAudit(nil), WithAuditCheckpoint(1, nil),
+		WithClock(clock), WithFileSystem(fs))
+	flog.Info(context.TODO(), "one")
+	flog.Close()
+
+	if data := string(fs.node("/logs/audit.log").data); !strings.Contains(data, `"time":"2026-10-17T12:00:00Z"`) {
+		t.Errorf("checkpoint not timed by the clock in %s", data)
+	}
+}
@@ -0,0 +1,305 @@
+package log4go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchStats counts the entries handled by a batching logger.
+type BatchStats struct {
+	// Sent is the number of entries delivered.
+	Sent uint64
+	// Dropped is the number of entries discarded because the buffer was
+	// full.
+	Dropped uint64
+	// Failed is the number of entries discarded because their batch could
+	// not be delivered after all retries.
+	Failed uint64
+	// Retries is the number of failed attempts which were retried.
+	Retries uint64
+}
+
+// batchItem is an entry waiting in a batch, with its size accounted
+// against the buffer limit.
+type batchItem struct {
+	payload interface{}
+	size    int
+}
+
+// batcher buffers items and hands them over in batches, when BatchSize
+// items are waiting, every BatchInterval, and on flush. Items arriving
+// while BatchMaxBytes are waiting are dropped.
+type batcher struct {
+	// stats is first so that its 64-bit counters, used atomically, stay
+	// aligned on 32-bit platforms.
+	stats BatchStats
+
+	size     int
+	interval time.Duration
+	maxBytes int
+	send     func(items []batchItem) error
+
+	mu      sync.Mutex
+	items   []batchItem
+	bytes   int
+	sending sync.Mutex
+
+	wake   chan struct{}
+	syncs  chan chan error
+	stop   chan struct{}
+	done   chan struct{}
+	closed int32
+}
+
+func newBatcher(opts Options, send func(items []batchItem) error) *batcher {
+	b := &batcher{
+		size:     opts.BatchSize,
+		interval: opts.BatchInterval,
+		maxBytes: opts.BatchMaxBytes,
+		send:     send,
+		wake:     make(chan struct{}, 1),
+		syncs:    make(chan chan error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if b.size <= 0 {
+		b.size = 100
+	}
+	if b.interval <= 0 {
+		b.interval = time.Second
+	}
+	go b.run()
+	return b
+}
+
+// add queues the item, or drops it if the buffer is full.
+func (b *batcher) add(item batchItem) {
+	b.mu.Lock()
+	if b.maxBytes > 0 && b.bytes+item.size > b.maxBytes {
+		b.mu.Unlock()
+		atomic.AddUint64(&b.stats.Dropped, 1)
+		return
+	}
+	b.items = append(b.items, item)
+	b.bytes += item.size
+	full := len(b.items) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		case res := <-b.syncs:
+			res <- b.flush()
+			continue
+		}
+		b.flush()
+	}
+}
+
+// sync has the background goroutine send the waiting items, and waits for
+// them at most timeout, so that the callers aren't blocked by the retries
+// while the endpoint is down.
+func (b *batcher) sync(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	res := make(chan error, 1)
+	select {
+	case b.syncs <- res:
+	case <-b.done:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("log4go: batch flush still running after %v", timeout)
+	}
+	select {
+	case err := <-res:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("log4go: batch flush still running after %v", timeout)
+	}
+}
+
+// flush sends all the waiting items, in batches of at most size items.
+func (b *batcher) flush() error {
+	b.sending.Lock()
+	defer b.sending.Unlock()
+
+	var firstErr error
+	for {
+		b.mu.Lock()
+		n := len(b.items)
+		if n == 0 {
+			b.mu.Unlock()
+			return firstErr
+		}
+		if n > b.size {
+			n = b.size
+		}
+		batch := make([]batchItem, n)
+		copy(batch, b.items)
+		b.items = b.items[n:]
+		for _, item := range batch {
+			b.bytes -= item.size
+		}
+		if len(b.items) == 0 {
+			b.items = nil
+		}
+		b.mu.Unlock()
+
+		if err := b.send(batch); err != nil {
+			atomic.AddUint64(&b.stats.Failed, uint64(len(batch)))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		atomic.AddUint64(&b.stats.Sent, uint64(len(batch)))
+	}
+}
+
+// close stops the background flushes, and sends the waiting items.
+func (b *batcher) close() error {
+	if !atomic.CompareAndSwapInt32(&b.closed, 0, 1) {
+		return nil
+	}
+	close(b.stop)
+	<-b.done
+	return b.flush()
+}
+
+// countRetry counts a failed attempt which is retried.
+func (b *batcher) countRetry() {
+	atomic.AddUint64(&b.stats.Retries, 1)
+}
+
+func (b *batcher) Stats() BatchStats {
+	return BatchStats{
+		Sent:    atomic.LoadUint64(&b.stats.Sent),
+		Dropped: atomic.LoadUint64(&b.stats.Dropped),
+		Failed:  atomic.LoadUint64(&b.stats.Failed),
+		Retries: atomic.LoadUint64(&b.stats.Retries),
+	}
+}
+
+// backoffDelay returns the delay before the attempt, doubling from min up
+// to max, with up to 20% jitter so that clients don't retry in lockstep.
+func backoffDelay(attempt int, min, max time.Duration) time.Duration {
+	d := min
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if jitter := int64(d) / 5; jitter > 0 {
+		d += time.Duration(rand.Int63n(jitter))
+	}
+	return d
+}
+
+// httpPoster posts request bodies, compressed and with the configured
+// headers, retrying with exponential backoff on network errors, 429 and
+// 5xx responses.
+type httpPoster struct {
+	url        string
+	client     *http.Client
+	headers    map[string]string
+	compress   bool
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	stats      *BatchStats
+}
+
+func newHTTPPoster(url string, opts Options, stats *BatchStats) *httpPoster {
+	p := &httpPoster{
+		url:        url,
+		client:     opts.HTTPClient,
+		headers:    opts.HTTPHeaders,
+		compress:   opts.Compress,
+		retries:    opts.RetryMax,
+		minBackoff: opts.RetryBackoff,
+		maxBackoff: opts.RetryMaxBackoff,
+		stats:      stats,
+	}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if p.minBackoff <= 0 {
+		p.minBackoff = 500 * time.Millisecond
+	}
+	if p.maxBackoff < p.minBackoff {
+		p.maxBackoff = 30 * time.Second
+	}
+	return p
+}
+
+func (p *httpPoster) post(body []byte, contentType string) error {
+	if p.compress {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		body = zbuf.Bytes()
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = p.try(body, contentType); err == nil || !retry || attempt >= p.retries {
+			return err
+		}
+		atomic.AddUint64(&p.stats.Retries, 1)
+		time.Sleep(backoffDelay(attempt, p.minBackoff, p.maxBackoff))
+	}
+}
+
+// try posts the body once, and reports whether a failure may be retried.
+func (p *httpPoster) try(body []byte, contentType string) (bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if p.compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	err = fmt.Errorf("log4go: post %s: %s: %s", p.url, resp.Status, bytes.TrimSpace(msg))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
@@ -0,0 +1,104 @@
+// Command log4go-decrypt writes the entries of log files encrypted by
+// log4go to the standard output.
+//
+// Usage:
+//
+//	log4go-decrypt -key-file key.hex [-key-id id] [file ...]
+//
+// The key file holds the hex encoded AES key. The files are read in order,
+// the standard input if none, and those rotated and compressed with gzip
+// are decompressed first.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rumis/log4go"
+)
+
+// anyKey decrypts the segments of any key id with key.
+type anyKey []byte
+
+func (k anyKey) EncryptionKey() (string, []byte, error) {
+	return "", k, nil
+}
+
+func (k anyKey) DecryptionKey(id string) ([]byte, error) {
+	return k, nil
+}
+
+func main() {
+	keyFile := flag.String("key-file", "", "file holding the hex encoded key")
+	keyID := flag.String("key-id", "", "id of the key, any if empty")
+	flag.Parse()
+	if *keyFile == "" {
+		fmt.Fprintln(os.Stderr, "log4go-decrypt: -key-file is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	data, err := os.ReadFile(*keyFile)
+	if err != nil {
+		fatal(err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		fatal(fmt.Errorf("read key: %w", err))
+	}
+	keys := log4go.KeyProvider(anyKey(key))
+	if *keyID != "" {
+		keys = log4go.StaticKey(*keyID, key)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if flag.NArg() == 0 {
+		if err := decrypt(out, os.Stdin, keys); err != nil {
+			out.Flush()
+			fatal(err)
+		}
+		return
+	}
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			out.Flush()
+			fatal(err)
+		}
+		err = decrypt(out, f, keys)
+		f.Close()
+		if err != nil {
+			out.Flush()
+			fatal(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+}
+
+// decrypt writes the entries of the encrypted log r to w, decompressing r
+// first if compressed with gzip.
+func decrypt(w io.Writer, r io.Reader, keys log4go.KeyProvider) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+	_, err := io.Copy(w, log4go.NewDecryptReader(r, keys))
+	return err
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "log4go-decrypt:", err)
+	os.Exit(1)
+}
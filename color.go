@@ -0,0 +1,245 @@
+package log4go
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ANSI escape sequences used to colorize console output.
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+)
+
+var (
+	_colorPool = buffer.NewPool()
+
+	levelColors = map[Level]string{
+		DebugLevel: colorMagenta,
+		InfoLevel:  colorBlue,
+		WarnLevel:  colorYellow,
+		ErrorLevel: colorRed,
+		PanicLevel: colorBold + colorRed,
+		FatalLevel: colorBold + colorRed,
+	}
+)
+
+// levelColor return the color of the level, red for unknown levels.
+func levelColor(l Level) string {
+	if c, ok := levelColors[l]; ok {
+		return c
+	}
+	return colorRed
+}
+
+// colorEnabled reports whether console output written to w should be
+// colorized. Colors are always used if ForceColor is set. Otherwise they are
+// used only if requested, and then only if w is a terminal and the NO_COLOR
+// environment variable is not set.
+func colorEnabled(opts Options, w io.Writer) bool {
+	if opts.ForceColor {
+		return true
+	}
+	if !opts.Color {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorArrayEncoder wraps every string appended into the color.
+type colorArrayEncoder struct {
+	zapcore.PrimitiveArrayEncoder
+	color string
+}
+
+func (c colorArrayEncoder) AppendString(s string) {
+	c.PrimitiveArrayEncoder.AppendString(c.color + s + colorReset)
+}
+
+// colorLevelEncoder returns a LevelEncoder which colorizes the output of enc
+// according to the level.
+func colorLevelEncoder(enc zapcore.LevelEncoder) zapcore.LevelEncoder {
+	return func(l zapcore.Level, arr zapcore.PrimitiveArrayEncoder) {
+		enc(l, colorArrayEncoder{PrimitiveArrayEncoder: arr, color: levelColor(l)})
+	}
+}
+
+// newColorConsoleEncoder create a console encoder with colored level, and
+// optionally highlighted message and colored field keys and values.
+func newColorConsoleEncoder(cfg EncoderConfig, opts Options) Encoder {
+	cfg.EncodeLevel = colorLevelEncoder(cfg.EncodeLevel)
+	if !opts.ColorFields && !opts.ColorMessage {
+		return zapcore.NewConsoleEncoder(cfg)
+	}
+
+	// the structured context is kept in a bare json encoder, so that it can
+	// be colorized separately from the console line.
+	ctxCfg := cfg
+	ctxCfg.TimeKey = ""
+	ctxCfg.LevelKey = ""
+	ctxCfg.NameKey = ""
+	ctxCfg.CallerKey = ""
+	ctxCfg.FunctionKey = ""
+	ctxCfg.MessageKey = ""
+	ctxCfg.StacktraceKey = ""
+	ctxCfg.SkipLineEnding = true
+
+	if cfg.ConsoleSeparator == "" {
+		cfg.ConsoleSeparator = "\t"
+	}
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	}
+	return &colorEncoder{
+		Encoder: zapcore.NewJSONEncoder(ctxCfg),
+		console: zapcore.NewConsoleEncoder(cfg),
+		cfg:     cfg,
+		fields:  opts.ColorFields,
+		message: opts.ColorMessage,
+	}
+}
+
+// colorEncoder writes the same layout as the console encoder, with the
+// message highlighted and the keys and values of the structured context
+// colorized.
+type colorEncoder struct {
+	// Encoder accumulates the structured context
+	zapcore.Encoder
+	console zapcore.Encoder
+	cfg     EncoderConfig
+	fields  bool
+	message bool
+}
+
+func (c *colorEncoder) Clone() zapcore.Encoder {
+	clone := *c
+	clone.Encoder = c.Encoder.Clone()
+	return &clone
+}
+
+func (c *colorEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if c.message && ent.Message != "" {
+		ent.Message = colorBold + ent.Message + colorReset
+	}
+	stack := ent.Stack
+	ent.Stack = ""
+
+	head, err := c.console.EncodeEntry(ent, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+	context, err := c.Encoder.Clone().EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer context.Free()
+
+	line := _colorPool.Get()
+	line.Write(bytes.TrimSuffix(head.Bytes(), []byte(c.cfg.LineEnding)))
+	if ctx := context.Bytes(); len(ctx) > 2 {
+		if line.Len() > 0 {
+			line.AppendString(c.cfg.ConsoleSeparator)
+		}
+		if c.fields {
+			colorizeJSON(line, ctx)
+		} else {
+			spaceJSON(line, ctx)
+		}
+	}
+	if stack != "" && c.cfg.StacktraceKey != "" {
+		line.AppendByte('\n')
+		line.AppendString(stack)
+	}
+	line.AppendString(c.cfg.LineEnding)
+	return line, nil
+}
+
+// colorizeJSON copies the compact json object src into line, in the spaced
+// layout of the console encoder, with keys and values colorized.
+func colorizeJSON(line *buffer.Buffer, src []byte) {
+	writeJSON(line, src, colorCyan, colorGreen)
+}
+
+// spaceJSON copies the compact json object src into line, in the spaced
+// layout of the console encoder.
+func spaceJSON(line *buffer.Buffer, src []byte) {
+	writeJSON(line, src, "", "")
+}
+
+func writeJSON(line *buffer.Buffer, src []byte, keyColor, valueColor string) {
+	paint := func(tok []byte, color string) {
+		if color == "" {
+			line.Write(tok)
+			return
+		}
+		line.AppendString(color)
+		line.Write(tok)
+		line.AppendString(colorReset)
+	}
+	for i := 0; i < len(src); {
+		switch b := src[i]; b {
+		case '{', '}', '[', ']':
+			line.AppendByte(b)
+			i++
+		case ':', ',':
+			line.AppendByte(b)
+			line.AppendByte(' ')
+			i++
+		case '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(src) {
+				end++
+			}
+			if end < len(src) && src[end] == ':' {
+				paint(src[i:end], keyColor)
+			} else {
+				paint(src[i:end], valueColor)
+			}
+			i = end
+		default:
+			end := i + 1
+			for end < len(src) && strings.IndexByte(`{}[]:,"`, src[end]) < 0 {
+				end++
+			}
+			paint(src[i:end], valueColor)
+			i = end
+		}
+	}
+}
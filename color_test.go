@@ -0,0 +1,43 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColorConsole(t *testing.T) {
+	ctx := context.TODO()
+
+	buf := &bytes.Buffer{}
+	NewConsoleLogger(WithOutput(buf), WithColor(true)).Info(ctx, "not a terminal")
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("color should be disabled when output is not a terminal, got %q", buf.String())
+	}
+
+	buf.Reset()
+	NewConsoleLogger(WithOutput(buf), WithStack(false), WithCaller(false),
+		WithColor(true), WithForceColor(true), WithColorFields(true), WithColorMessage(true),
+	).Error(ctx, "forced", String("k", "v"))
+	out := buf.String()
+	for _, want := range []string{
+		colorRed + "error" + colorReset,
+		colorBold + "forced" + colorReset,
+		"{" + colorCyan + `"k"` + colorReset + ": " + colorGreen + `"v"` + colorReset + "}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expect %q in %q", want, out)
+		}
+	}
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	if colorEnabled(Options{Color: true}, os.Stdout) {
+		t.Error("NO_COLOR should disable color")
+	}
+	if !colorEnabled(Options{Color: true, ForceColor: true}, buf) || !colorEnabled(Options{ForceColor: true}, buf) {
+		t.Error("ForceColor should enable color")
+	}
+}
@@ -0,0 +1,175 @@
+package log4go
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// errDiskFreeUnsupported is returned by diskFree where the free space can't
+// be queried.
+var errDiskFreeUnsupported = errors.New("log4go: free disk space not supported on this platform")
+
+// diskFree returns the bytes available to unprivileged users on the file
+// system holding dir, it can be replaced in tests.
+var diskFree = statDiskFree
+
+// DiskBudget bounds the total size of the rotated files of the file loggers
+// sharing it, typically the loggers writing to the same directory. When the
+// budget is exceeded, the oldest rotated files are removed first, whichever
+// logger they belong to. The files being written are never removed.
+type DiskBudget struct {
+	max int64
+
+	// enforcing serializes Enforce, mu guards writers and is never held
+	// while locking a writer
+	enforcing sync.Mutex
+	mu        sync.Mutex
+	writers   []*RotatingWriter
+}
+
+// NewDiskBudget create a new DiskBudget of maxBytes.
+func NewDiskBudget(maxBytes int64) *DiskBudget {
+	return &DiskBudget{max: maxBytes}
+}
+
+// add registers the writer, unless registered.
+func (b *DiskBudget) add(w *RotatingWriter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, x := range b.writers {
+		if x == w {
+			return
+		}
+	}
+	b.writers = append(b.writers, w)
+}
+
+// remove unregisters the writer, on Close.
+func (b *DiskBudget) remove(w *RotatingWriter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, x := range b.writers {
+		if x == w {
+			b.writers = append(b.writers[:i], b.writers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Enforce removes the oldest rotated files until their total size fits in
+// the budget. It is called after each rotation.
+func (b *DiskBudget) Enforce() error {
+	b.enforcing.Lock()
+	defer b.enforcing.Unlock()
+	b.mu.Lock()
+	writers := append([]*RotatingWriter(nil), b.writers...)
+	b.mu.Unlock()
+
+	type backup struct {
+		w    *RotatingWriter
+		path string
+		info os.FileInfo
+	}
+	var backups []backup
+	seen := make(map[string]bool)
+	var firstErr error
+	for _, w := range writers {
+		current := w.Filename()
+		if current == "" {
+			current = w.currentName(w.now())
+		}
+		infos, err := w.backups(current)
+		if err != nil {
+			if !os.IsNotExist(err) && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, info := range infos {
+			path := filepath.Join(filepath.Dir(current), info.Name())
+			if !seen[path] {
+				seen[path] = true
+				backups = append(backups, backup{w: w, path: path, info: info})
+			}
+		}
+	}
+
+	var total int64
+	for _, bk := range backups {
+		total += bk.info.Size()
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].info.ModTime().Before(backups[j].info.ModTime()) })
+	for _, bk := range backups {
+		if total <= b.max {
+			break
+		}
+		if bk.w.isLive(bk.path) {
+			continue
+		}
+		if err := bk.w.fs.Remove(bk.path); err != nil {
+			if firstErr == nil && !os.IsNotExist(err) {
+				firstErr = err
+			}
+			continue
+		}
+		total -= bk.info.Size()
+	}
+	return firstErr
+}
+
+// lowDiskGuard disables the levels below level while the free space of the
+// file system holding dir is under minFree bytes. The free space is checked
+// at most once per interval.
+type lowDiskGuard struct {
+	// next is the unix nanoseconds of the next check. It is first so that
+	// it stays aligned for atomics on 32-bit platforms.
+	next int64
+
+	dir      string
+	minFree  uint64
+	level    Level
+	interval time.Duration
+	clock    Clock
+	low      int32
+}
+
+func newLowDiskGuard(dir string, minFree int64, level Level, clock Clock) *lowDiskGuard {
+	return &lowDiskGuard{dir: dir, minFree: uint64(minFree), level: level, interval: 5 * time.Second, clock: clock}
+}
+
+func (g *lowDiskGuard) Enabled(l Level) bool {
+	if l >= g.level {
+		return true
+	}
+	now := g.clock.Now().UnixNano()
+	if next := atomic.LoadInt64(&g.next); now >= next && atomic.CompareAndSwapInt64(&g.next, next, now+int64(g.interval)) {
+		var low int32
+		if free, err := diskFree(g.dir); err == nil && free < g.minFree {
+			low = 1
+		}
+		atomic.StoreInt32(&g.low, low)
+	}
+	return atomic.LoadInt32(&g.low) == 0
+}
+
+// andEnablers enables the levels enabled by both a and b, nil standing for
+// all levels.
+func andEnablers(a, b zapcore.LevelEnabler) zapcore.LevelEnabler {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return zap.LevelEnablerFunc(func(l Level) bool {
+		return a.Enabled(l) && b.Enabled(l)
+	})
+}
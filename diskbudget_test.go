@@ -0,0 +1,59 @@
+package log4go
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskBudget(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	budget := NewDiskBudget(25)
+	app := NewRotatingWriter(WithFileName("/logs/app.log"), WithDiskBudget(budget), WithClock(clock), WithFileSystem(fs))
+	db := NewRotatingWriter(WithFileName("/logs/db.log"), WithDiskBudget(budget), WithClock(clock), WithFileSystem(fs))
+	app.maxSize, db.maxSize = 10, 10
+
+	// app rotates at 1s and 3s, db at 2s and 4s, 10 bytes each
+	for i := 0; i < 3; i++ {
+		app.Write([]byte("0123456789"))
+		clock.Advance(time.Second)
+		db.Write([]byte("0123456789"))
+		clock.Advance(time.Second)
+	}
+	app.Close()
+	db.Close()
+	if len(budget.writers) != 0 {
+		t.Errorf("%d writers left in the budget after Close", len(budget.writers))
+	}
+
+	want := []string{
+		"/logs/app-2026-10-17T00-00-04.000.log",
+		"/logs/app.log",
+		"/logs/db-2026-10-17T00-00-05.000.log",
+		"/logs/db.log",
+	}
+	if got := fs.names(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files %v, want %v", got, want)
+	}
+}
+
+func TestLowDiskGuard(t *testing.T) {
+	free := uint64(100)
+	defer func(fn func(string) (uint64, error)) { diskFree = fn }(diskFree)
+	diskFree = func(string) (uint64, error) { return free, nil }
+
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	g := newLowDiskGuard("/logs", 50, InfoLevel, clock)
+	if !g.Enabled(DebugLevel) {
+		t.Error("debug disabled with enough space")
+	}
+	free = 10
+	if !g.Enabled(DebugLevel) {
+		t.Error("free space checked again before the interval")
+	}
+	clock.Advance(g.interval)
+	if g.Enabled(DebugLevel) || !g.Enabled(InfoLevel) {
+		t.Error("debug enabled or info disabled on low space")
+	}
+}
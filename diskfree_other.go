@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package log4go
+
+func statDiskFree(dir string) (uint64, error) {
+	return 0, errDiskFreeUnsupported
+}
@@ -0,0 +1,219 @@
+package log4go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ECSEncoding writes each entry as a JSON document following the Elastic
+// Common Schema.
+const ECSEncoding = "ecs"
+
+// ECSVersion is the version of the Elastic Common Schema written in the
+// ecs.version field.
+const ECSVersion = "8.11.0"
+
+// ecsFieldAliases maps the common field keys to their ECS field name.
+var ecsFieldAliases = map[string]string{
+	"trace_id":       "trace.id",
+	"span_id":        "span.id",
+	"transaction_id": "transaction.id",
+	"errorVerbose":   "error.stack_trace",
+}
+
+// ecsEntryFields are the fields written from the entry, the fields of the
+// same name are written in labels instead.
+var ecsEntryFields = map[string]bool{
+	"@timestamp":  true,
+	"message":     true,
+	"ecs":         true,
+	"ecs.version": true,
+	"log":         true,
+	"log.level":   true,
+	"log.logger":  true,
+	"log.origin":  true,
+}
+
+var _ecsPool = buffer.NewPool()
+
+// NewECSEncoder creates an encoder which writes each entry as an Elastic
+// Common Schema document:
+//
+//	{"@timestamp":"2006-01-02T15:04:05.999999999Z","ecs":{"version":"8.11.0"},
+//	 "log":{"level":"info","logger":"app","origin":{"file":{"line":12,"name":"main.go"},"function":"main.main"}},
+//	 "message":"hello","trace":{"id":"..."}}
+//
+// Fields with dotted keys, such as "http.request.method", are nested in
+// objects, or kept dotted if a field is named as their prefix; the keys trace_id, span_id and transaction_id are written as
+// trace.id, span.id and transaction.id, and error fields are written as
+// error.message and error.stack_trace. Fields named as the fields of the
+// entry, such as "message" or "log", are written in the labels object. The
+// key options of the encoder config are ignored, the names are fixed by the
+// schema.
+func NewECSEncoder(cfg EncoderConfig) Encoder {
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	}
+	return &ecsEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              &cfg,
+	}
+}
+
+// ecsEncoder collects the fields in a map, and writes the document nested
+// by their dotted names.
+type ecsEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg *EncoderConfig
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return &ecsEncoder{MapObjectEncoder: clone, cfg: e.cfg}
+}
+
+func (e *ecsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := e.Clone().(*ecsEncoder)
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+
+	doc := make(map[string]interface{}, len(enc.Fields)+6)
+	// sorted, a scalar comes before the dotted names it prefixes, which are
+	// then kept dotted, whatever the order of the map
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := ecsValue(enc.Fields[k])
+		if ecsEntryFields[k] || strings.HasPrefix(k, "log.origin.") {
+			labels, ok := doc["labels"].(map[string]interface{})
+			if !ok {
+				labels = make(map[string]interface{})
+				doc["labels"] = labels
+			}
+			labels[k] = v
+			continue
+		}
+		if k == "error" {
+			if s, ok := v.(string); ok {
+				putECSField(doc, "error.message", s)
+				continue
+			}
+		}
+		if alias, ok := ecsFieldAliases[k]; ok {
+			k = alias
+		}
+		putECSField(doc, k, v)
+	}
+
+	putECSField(doc, "@timestamp", ent.Time.UTC().Format(time.RFC3339Nano))
+	putECSField(doc, "log.level", ent.Level.String())
+	putECSField(doc, "message", ent.Message)
+	putECSField(doc, "ecs.version", ECSVersion)
+	if ent.LoggerName != "" {
+		putECSField(doc, "log.logger", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		putECSField(doc, "log.origin.file.name", ent.Caller.File)
+		putECSField(doc, "log.origin.file.line", ent.Caller.Line)
+		if ent.Caller.Function != "" {
+			putECSField(doc, "log.origin.function", ent.Caller.Function)
+		}
+	}
+	if ent.Stack != "" {
+		putECSField(doc, "error.stack_trace", ent.Stack)
+	}
+
+	line := _ecsPool.Get()
+	jenc := json.NewEncoder(line)
+	jenc.SetEscapeHTML(false)
+	if err := jenc.Encode(doc); err != nil {
+		line.Free()
+		return nil, err
+	}
+	// json.Encoder always ends the document with a newline
+	line.TrimNewline()
+	line.AppendString(e.cfg.LineEnding)
+	return line, nil
+}
+
+// ecsValue converts the values JSON can't encode: NaN and infinite floats
+// and complex numbers are written as strings, and the reflected values which
+// fail to encode as their fmt form.
+func ecsValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		[]byte, time.Time, time.Duration:
+		return v
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return formatFloat(float64(v), 32)
+		}
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatFloat(v, 64)
+		}
+		return v
+	case complex64:
+		return formatComplex(complex128(v), 32)
+	case complex128:
+		return formatComplex(v, 64)
+	case map[string]interface{}:
+		// the objects of the With fields are shared by the entries
+		m := make(map[string]interface{}, len(v))
+		for k, x := range v {
+			m[k] = ecsValue(x)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, x := range v {
+			a[i] = ecsValue(x)
+		}
+		return a
+	}
+	if _, err := json.Marshal(val); err != nil {
+		return fmt.Sprint(val)
+	}
+	return val
+}
+
+// putECSField sets the value at the dotted name in doc, creating the nested
+// objects on the way. If the name goes through a value which isn't an
+// object, the rest of the name is kept dotted in the last object.
+func putECSField(doc map[string]interface{}, name string, val interface{}) {
+	parts := strings.Split(name, ".")
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := doc[part]
+		if !ok {
+			child := make(map[string]interface{})
+			doc[part] = child
+			doc = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			doc[strings.Join(parts[i:], ".")] = val
+			return
+		}
+		doc = child
+	}
+	doc[parts[len(parts)-1]] = val
+}
@@ -0,0 +1,108 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestECSEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wlog := NewWriterLogger(buf, WithEncoding(ECSEncoding), WithName("app"), WithStack(false))
+
+	ctx := context.WithValue(context.TODO(), ContextFieldsKey, []Field{String("trace_id", "t1")})
+	wlog.Error(ctx, "ecs test",
+		String("http.request.method", "GET"),
+		Int("http.response.status_code", 500),
+		Field{Key: "error", Type: ErrorType, Interface: errors.New("boom")})
+
+	var doc struct {
+		Timestamp string `json:"@timestamp"`
+		Message   string `json:"message"`
+		Log       struct {
+			Level  string `json:"level"`
+			Logger string `json:"logger"`
+			Origin struct {
+				File struct {
+					Name string `json:"name"`
+					Line int    `json:"line"`
+				} `json:"file"`
+			} `json:"origin"`
+		} `json:"log"`
+		ECS struct {
+			Version string `json:"version"`
+		} `json:"ecs"`
+		Trace struct {
+			ID string `json:"id"`
+		} `json:"trace"`
+		HTTP struct {
+			Request struct {
+				Method string `json:"method"`
+			} `json:"request"`
+			Response struct {
+				StatusCode int `json:"status_code"`
+			} `json:"response"`
+		} `json:"http"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	if doc.Timestamp == "" || doc.Message != "ecs test" || doc.Log.Level != "error" || doc.Log.Logger != "app" ||
+		doc.ECS.Version != ECSVersion || doc.Trace.ID != "t1" || doc.Error.Message != "boom" ||
+		doc.HTTP.Request.Method != "GET" || doc.HTTP.Response.StatusCode != 500 {
+		t.Errorf("unexpected ecs document %s", buf.String())
+	}
+	if !strings.HasSuffix(doc.Log.Origin.File.Name, "ecs_test.go") || doc.Log.Origin.File.Line == 0 {
+		t.Errorf("unexpected origin in %s", buf.String())
+	}
+}
+
+func TestECSEncoderFieldValues(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wlog := NewWriterLogger(buf, WithEncoding(ECSEncoding))
+
+	wlog.Info(context.TODO(), "values",
+		Float64("ratio", math.NaN()),
+		Field(zap.Complex128("c", complex(1, 2))),
+		String("message", "user message"),
+		String("log", "user log"))
+
+	var doc struct {
+		Message string                 `json:"message"`
+		Ratio   string                 `json:"ratio"`
+		C       string                 `json:"c"`
+		Labels  map[string]interface{} `json:"labels"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	if doc.Message != "values" || doc.Ratio != "NaN" || doc.C != "1+2i" ||
+		doc.Labels["message"] != "user message" || doc.Labels["log"] != "user log" {
+		t.Errorf("unexpected ecs document %s", buf.String())
+	}
+}
+
+func TestECSEncoderDottedCollision(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		buf := &bytes.Buffer{}
+		wlog := NewWriterLogger(buf, WithEncoding(ECSEncoding))
+		wlog.Info(context.TODO(), "collision", String("http.method", "GET"), String("http", "h"), Int("http.status", 200))
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("unmarshal %q: %v", buf.String(), err)
+		}
+		if doc["http"] != "h" || doc["http.method"] != "GET" || doc["http.status"] != 200.0 {
+			t.Fatalf("unexpected ecs document %s", buf.String())
+		}
+	}
+}
@@ -45,6 +45,24 @@ func LowercaseLevelEncoder(l Level, enc PrimitiveArrayEncoder) {
 	enc.AppendString(zapcore.Level(l).String())
 }
 
+// LowercaseColorLevelEncoder serializes a Level to a lowercase string and adds
+// coloring. For example, InfoLevel is serialized to "info" and colored blue.
+func LowercaseColorLevelEncoder(l Level, enc PrimitiveArrayEncoder) {
+	enc.AppendString(levelColor(l) + zapcore.Level(l).String() + colorReset)
+}
+
+// CapitalLevelEncoder serializes a Level to an all-caps string. For example,
+// InfoLevel is serialized to "INFO".
+func CapitalLevelEncoder(l Level, enc PrimitiveArrayEncoder) {
+	enc.AppendString(zapcore.Level(l).CapitalString())
+}
+
+// CapitalColorLevelEncoder serializes a Level to an all-caps string and adds
+// coloring. For example, InfoLevel is serialized to "INFO" and colored blue.
+func CapitalColorLevelEncoder(l Level, enc PrimitiveArrayEncoder) {
+	enc.AppendString(levelColor(l) + zapcore.Level(l).CapitalString() + colorReset)
+}
+
 // TimeEncoderOfLayout returns TimeEncoder which serializes a time.Time using
 // given layout.
 func TimeEncoderOfLayout(layout string) TimeEncoder {
@@ -0,0 +1,109 @@
+package log4go
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Encoder is a format-agnostic interface for all log entry marshalers.
+type Encoder = zapcore.Encoder
+
+// EncoderConfig allows users to configure the concrete encoders.
+type EncoderConfig = zapcore.EncoderConfig
+
+// EncoderFactory creates an Encoder from the zap encoder config and the
+// options of the logger being built.
+type EncoderFactory func(cfg EncoderConfig, opts Options) (Encoder, error)
+
+const (
+	// JSONEncoding writes each entry as a JSON object.
+	JSONEncoding = "json"
+	// ConsoleEncoding writes each entry as human-readable, separator delimited text.
+	ConsoleEncoding = "console"
+)
+
+var (
+	errNoEncoderName = errors.New("log4go: no encoder name specified")
+
+	encoderMutex sync.RWMutex
+	encoders     = map[string]EncoderFactory{
+		JSONEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			return zapcore.NewJSONEncoder(cfg), nil
+		},
+		ConsoleEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			if opts.Color {
+				return newColorConsoleEncoder(cfg, opts), nil
+			}
+			return zapcore.NewConsoleEncoder(cfg), nil
+		},
+		LogfmtEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			return NewLogfmtEncoder(cfg), nil
+		},
+		ECSEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			return NewECSEncoder(cfg), nil
+		},
+		GELFEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			return NewGELFEncoder(cfg, opts.Hostname), nil
+		},
+		SyslogEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			return NewSyslogEncoder(cfg, opts), nil
+		},
+		JournaldEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			return NewJournaldEncoder(cfg, opts.AppName), nil
+		},
+		PatternEncoding: func(cfg EncoderConfig, opts Options) (Encoder, error) {
+			if opts.Pattern == "" {
+				return NewPatternEncoder(DefaultPattern, cfg)
+			}
+			return NewPatternEncoder(opts.Pattern, cfg)
+		},
+	}
+)
+
+// RegisterEncoder registers an encoder factory for the given name, so that
+// it can be selected with WithEncoding. Attempting to register an encoder
+// whose name is already taken returns an error.
+func RegisterEncoder(name string, factory EncoderFactory) error {
+	if name == "" {
+		return errNoEncoderName
+	}
+	if factory == nil {
+		return fmt.Errorf("log4go: nil factory for encoder %q", name)
+	}
+	encoderMutex.Lock()
+	defer encoderMutex.Unlock()
+	if _, ok := encoders[name]; ok {
+		return fmt.Errorf("log4go: encoder already registered for name %q", name)
+	}
+	encoders[name] = factory
+	return nil
+}
+
+// newEncoder create the encoder selected by opts.Encoding. An empty name
+// selects fallback; an unknown or failing encoder is reported on stderr and
+// also replaced by fallback, so a typo never silences the logger.
+func newEncoder(cfg EncoderConfig, opts Options, fallback string) Encoder {
+	name := opts.Encoding
+	if name == "" {
+		name = fallback
+	}
+	encoderMutex.RLock()
+	factory, ok := encoders[name]
+	defaultFactory := encoders[fallback]
+	encoderMutex.RUnlock()
+
+	if !ok {
+		fmt.Fprintf(os.Stderr, "log4go: no encoder registered for name %q, using %q\n", name, fallback)
+		factory = defaultFactory
+	}
+	enc, err := factory(cfg, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log4go: create encoder %q failed: %v, using %q\n", name, err, fallback)
+		enc, _ = defaultFactory(cfg, opts)
+	}
+	return enc
+}
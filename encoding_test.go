@@ -0,0 +1,57 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRegisterEncoder(t *testing.T) {
+	const name = "registry-test"
+	err := RegisterEncoder(name, func(cfg EncoderConfig, opts Options) (Encoder, error) {
+		cfg.MessageKey = "message"
+		return zapcore.NewJSONEncoder(cfg), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterEncoder(name, func(cfg EncoderConfig, opts Options) (Encoder, error) {
+		return zapcore.NewConsoleEncoder(cfg), nil
+	}); err == nil {
+		t.Error("register a duplicate encoder name should fail")
+	}
+	if err := RegisterEncoder("", nil); err != errNoEncoderName {
+		t.Errorf("register an empty name: %v", err)
+	}
+	if err := RegisterEncoder("registry-nil", nil); err == nil {
+		t.Error("register a nil factory should fail")
+	}
+
+	ctx := context.TODO()
+	buf := &bytes.Buffer{}
+	NewWriterLogger(buf, WithEncoding(name), WithStack(false)).Info(ctx, "custom")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry["message"] != "custom" {
+		t.Errorf("unexpected custom encoder output %q: %v", buf.String(), err)
+	}
+
+	// an unknown name, or a failing factory, fall back to the default
+	// encoder of the logger
+	if err := RegisterEncoder("registry-failing", func(cfg EncoderConfig, opts Options) (Encoder, error) {
+		return nil, errors.New("boom")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, enc := range []string{"registry-unknown", "registry-failing"} {
+		buf.Reset()
+		NewWriterLogger(buf, WithEncoding(enc), WithStack(false)).Info(ctx, "fallback")
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || !strings.Contains(buf.String(), `"msg":"fallback"`) {
+			t.Errorf("%s: unexpected fallback output %q: %v", enc, buf.String(), err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package log4go
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The encrypted log files are made of segments, one per opening of the file
+// by a writer. A segment starts with a header record
+//
+//	'H' "L4GE" version:1 len(keyID):1 keyID noncePrefix:8 offset:8
+//
+// where offset is the position of the header in the file,
+// followed by frame records, one per log entry,
+//
+//	'F' len(ciphertext):4 ciphertext
+//
+// sealed by AES-GCM with the nonce noncePrefix followed by the index of the
+// frame in the segment, and the header and the index as additional data, so
+// that frames can't be edited, reordered or moved to other segments. A
+// frame cut short, as by a crash while writing, is skipped by the reader:
+// at the end of the file, or when the next segment starts within it at the
+// offset recorded by its header. Any other frame failing authentication is
+// an error.
+
+const (
+	encryptionVersion = 1
+	noncePrefixSize   = 8
+)
+
+var encryptionMagic = []byte{'H', 'L', '4', 'G', 'E', encryptionVersion}
+
+// ErrDecrypt is returned when a frame of an encrypted log file fails
+// authentication.
+var ErrDecrypt = errors.New("log4go: decrypt log file failed")
+
+// KeyProvider supplies the AES keys of the encrypted log files, of 16, 24 or
+// 32 bytes, e.g. from a KMS. The id of the key encrypting a file is stored in
+// it in clear.
+type KeyProvider interface {
+	// EncryptionKey returns the key encrypting new segments, and its id of
+	// at most 255 bytes.
+	EncryptionKey() (id string, key []byte, err error)
+	// DecryptionKey returns the key of id.
+	DecryptionKey(id string) ([]byte, error)
+}
+
+type staticKey struct {
+	id  string
+	key []byte
+}
+
+// StaticKey returns a KeyProvider of a single key.
+func StaticKey(id string, key []byte) KeyProvider {
+	return &staticKey{id: id, key: key}
+}
+
+func (k *staticKey) EncryptionKey() (string, []byte, error) {
+	return k.id, k.key, nil
+}
+
+func (k *staticKey) DecryptionKey(id string) ([]byte, error) {
+	if id != k.id {
+		return nil, fmt.Errorf("log4go: unknown key %q", id)
+	}
+	return k.key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// frameNonce returns the nonce and additional data of the frame index of
+// the segment of header.
+func frameNonce(header, prefix []byte, index uint32) (nonce, ad []byte) {
+	nonce = appendBigEndian32(append(make([]byte, 0, 12), prefix...), index)
+	ad = appendBigEndian32(append(make([]byte, 0, len(header)+4), header...), index)
+	return nonce, ad
+}
+
+// encryptFile encrypts the writes to a File, a frame per write. The header
+// of the segment is written before the first frame.
+type encryptFile struct {
+	File
+	keys KeyProvider
+
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	index  uint32
+	buf    []byte
+}
+
+func newEncryptFile(f File, keys KeyProvider) *encryptFile {
+	return &encryptFile{File: f, keys: keys}
+}
+
+func (f *encryptFile) start() error {
+	id, key, err := f.keys.EncryptionKey()
+	if err != nil {
+		return err
+	}
+	if len(id) > 255 {
+		return fmt.Errorf("log4go: key id %q too long", id)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	info, err := f.File.Stat()
+	if err != nil {
+		return err
+	}
+	header := append([]byte{}, encryptionMagic...)
+	header = append(header, byte(len(id)))
+	header = append(header, id...)
+	header = append(header, prefix...)
+	header = appendBigEndian64(header, uint64(info.Size()))
+	if _, err := f.File.Write(header); err != nil {
+		return err
+	}
+	f.aead, f.header, f.prefix = aead, header, prefix
+	return nil
+}
+
+// Write writes p as a frame, returning len(p) on success.
+func (f *encryptFile) Write(p []byte) (int, error) {
+	if f.aead == nil {
+		if err := f.start(); err != nil {
+			return 0, err
+		}
+	}
+	nonce, ad := frameNonce(f.header, f.prefix, f.index)
+	f.buf = append(f.buf[:0], 'F', 0, 0, 0, 0)
+	f.buf = f.aead.Seal(f.buf, nonce, p, ad)
+	binary.BigEndian.PutUint32(f.buf[1:5], uint32(len(f.buf)-5))
+	if _, err := f.File.Write(f.buf); err != nil {
+		return 0, err
+	}
+	f.index++
+	return len(p), nil
+}
+
+// DecryptReader reads the entries of encrypted log files, written with the
+// Encryption option.
+type DecryptReader struct {
+	r    *bufio.Reader
+	keys KeyProvider
+
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	index  uint32
+	plain  []byte
+
+	// pos is the offset of the next record in the log. skipped is the error
+	// of a complete frame failing authentication, returned unless the
+	// segment found within it starts at the offset recorded by its header.
+	pos     int64
+	skipped error
+}
+
+// NewDecryptReader create a new DecryptReader of the encrypted log r, with
+// the keys of keys.
+func NewDecryptReader(r io.Reader, keys KeyProvider) *DecryptReader {
+	return &DecryptReader{r: bufio.NewReader(r), keys: keys}
+}
+
+func (d *DecryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next reads the next record, a header or a frame.
+func (d *DecryptReader) next() error {
+	kind, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case 'H':
+		err := d.readHeader()
+		if err != nil && d.skipped != nil {
+			return d.skipped
+		}
+		return err
+	case 'F':
+		return d.readFrame()
+	}
+	return fmt.Errorf("%w: unexpected record %q", ErrDecrypt, kind)
+}
+
+func (d *DecryptReader) readHeader() error {
+	fixed := make([]byte, len(encryptionMagic))
+	fixed[0] = 'H'
+	if _, err := io.ReadFull(d.r, fixed[1:]); err != nil {
+		return d.truncated(err)
+	}
+	if !bytes.Equal(fixed, encryptionMagic) {
+		return fmt.Errorf("%w: unsupported header %q", ErrDecrypt, fixed)
+	}
+	n, err := d.r.ReadByte()
+	if err != nil {
+		return d.truncated(err)
+	}
+	rest := make([]byte, int(n)+noncePrefixSize+8)
+	if _, err := io.ReadFull(d.r, rest); err != nil {
+		return d.truncated(err)
+	}
+	if d.skipped != nil && int64(binary.BigEndian.Uint64(rest[len(rest)-8:])) != d.pos {
+		return d.skipped
+	}
+	key, err := d.keys.DecryptionKey(string(rest[:n]))
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	d.aead = aead
+	d.header = append(append(fixed, n), rest...)
+	d.prefix = rest[n : int(n)+noncePrefixSize]
+	d.index = 0
+	d.pos += int64(len(d.header))
+	d.skipped = nil
+	return nil
+}
+
+func (d *DecryptReader) readFrame() error {
+	if d.aead == nil {
+		return fmt.Errorf("%w: frame before header", ErrDecrypt)
+	}
+	// the size is read along with the frame, which may hold the header of
+	// the next segment if the frame was cut short
+	var frame bytes.Buffer
+	complete := false
+	if _, err := io.CopyN(&frame, d.r, 4); err != nil && err != io.EOF {
+		return err
+	}
+	if frame.Len() == 4 {
+		size := int64(binary.BigEndian.Uint32(frame.Bytes()))
+		if _, err := io.CopyN(&frame, d.r, size); err != nil && err != io.EOF {
+			return err
+		}
+		if complete = int64(frame.Len()) == 4+size; complete {
+			nonce, ad := frameNonce(d.header, d.prefix, d.index)
+			plain, err := d.aead.Open(nil, nonce, frame.Bytes()[4:], ad)
+			if err == nil {
+				d.plain = plain
+				d.index++
+				d.pos += 1 + int64(frame.Len())
+				return nil
+			}
+		}
+	}
+	// The frame may have been cut short, either at the end of the log or by
+	// the segment of a writer appending to the log after a crash. A
+	// complete frame is only skipped if that segment is where it says.
+	data := frame.Bytes()
+	if i := bytes.Index(data, encryptionMagic); i >= 0 {
+		if complete {
+			d.skipped = fmt.Errorf("%w: frame %d", ErrDecrypt, d.index)
+		}
+		d.r = bufio.NewReader(io.MultiReader(bytes.NewReader(data[i:]), d.r))
+		d.aead = nil
+		d.pos += 1 + int64(i)
+		return nil
+	}
+	if !complete {
+		return io.EOF
+	}
+	return fmt.Errorf("%w: frame %d", ErrDecrypt, d.index)
+}
+
+// truncated returns io.EOF for a record cut short at the end of the log.
+func (d *DecryptReader) truncated(err error) error {
+	if err == io.ErrUnexpectedEOF {
+		return io.EOF
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptedFileLogger(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	keys := StaticKey("k1", bytes.Repeat([]byte{7}, 32))
+
+	// the second logger appends a new segment after a first frame cut short
+	flog := NewFileLogger(WithFileName(name), WithEncryption(keys), WithStack(false))
+	flog.Info(context.TODO(), "first")
+	flog.Info(context.TODO(), "second")
+	flog.Close()
+	data, _ := os.ReadFile(name)
+	if bytes.Contains(data, []byte("first")) {
+		t.Fatal("entry written in clear")
+	}
+	os.WriteFile(name, data[:len(data)-10], 0600)
+	flog = NewFileLogger(WithFileName(name), WithEncryption(keys), WithStack(false))
+	flog.Info(context.TODO(), "third")
+	flog.Close()
+
+	// the last frame cut short at the end is skipped
+	data, _ = os.ReadFile(name)
+	plain, err := io.ReadAll(NewDecryptReader(bytes.NewReader(data[:len(data)-3]), keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(plain); !strings.Contains(s, `"msg":"first"`) || strings.Contains(s, "second") || strings.Contains(s, "third") {
+		t.Errorf("decrypted %s", s)
+	}
+	plain, err = io.ReadAll(NewDecryptReader(bytes.NewReader(data), keys))
+	if err != nil || strings.Count(string(plain), "\n") != 2 || !strings.Contains(string(plain), `"msg":"third"`) {
+		t.Errorf("decrypted %s, %v", plain, err)
+	}
+
+	// an edited frame fails
+	data[len(data)-5] ^= 1
+	if _, err := io.ReadAll(NewDecryptReader(bytes.NewReader(data), keys)); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("edited frame read with %v", err)
+	}
+}
+
+func TestDecryptReaderSegmentOffset(t *testing.T) {
+	dir := t.TempDir()
+	keys := StaticKey("k1", bytes.Repeat([]byte{7}, 32))
+	write := func(name string, msgs ...string) []byte {
+		flog := NewFileLogger(WithFileName(filepath.Join(dir, name)), WithEncryption(keys), WithStack(false))
+		for _, msg := range msgs {
+			flog.Info(context.TODO(), msg)
+		}
+		flog.Close()
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		return data
+	}
+	data := write("a.log", "first", "second")
+	other := write("b.log", "other")
+
+	// the second frame is replaced by the segment of another file, within
+	// a complete frame failing authentication
+	header := len(encryptionMagic) + 1 + len("k1") + noncePrefixSize + 8
+	second := header + 5 + int(binary.BigEndian.Uint32(data[header+1:]))
+	forged := append(append([]byte{}, data[:second+5+4]...), other...)
+	binary.BigEndian.PutUint32(forged[second+1:], uint32(len(forged)-second-5))
+	plain, err := io.ReadAll(NewDecryptReader(bytes.NewReader(forged), keys))
+	if !errors.Is(err, ErrDecrypt) || strings.Contains(string(plain), "other") {
+		t.Errorf("forged segment read %q with %v", plain, err)
+	}
+}
+
+func TestEncryptedRotatingWriter(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	keys := StaticKey("k1", bytes.Repeat([]byte{7}, 16))
+	w := NewRotatingWriter(WithFileName("/logs/app.log"), WithEncryption(keys), WithClock(clock), WithFileSystem(fs))
+	w.Write([]byte("before\n"))
+	clock.Advance(time.Second)
+	w.Rotate()
+	w.Write([]byte("after\n"))
+	w.Close()
+
+	for name, want := range map[string]string{"/logs/app-2026-10-17T00-00-01.000.log": "before\n", "/logs/app.log": "after\n"} {
+		plain, err := io.ReadAll(NewDecryptReader(bytes.NewReader(fs.node(name).data), keys))
+		if err != nil || string(plain) != want {
+			t.Errorf("%s decrypted %q, %v", name, plain, err)
+		}
+	}
+}
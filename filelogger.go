@@ -1,22 +1,48 @@
 package log4go
 
 import (
-	"context"
 	"io"
-	"time"
+	"path/filepath"
 
-	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// LevelFile routes the entries of Level to their own file, configured by
+// the FileLogger options overridden by Options.
+type LevelFile struct {
+	Level   Level
+	Options []OptionHandler
+}
+
 // FileLogger file log base zap
 type FileLogger struct {
-	zap       *zap.Logger
-	extfields []Field
+	zapLogger
+	// writers holds the main file writer, followed by the level files
+	// writers.
+	writers []*RotatingWriter
 }
 
 // NewFileLogger create new file logger
+//
+// The file is rotated by a RotatingWriter when it reaches MaxSize
+// megabytes, and at the times of the RotationPolicy if set. If
+// FilenamePattern is set, the file of each period is named by expanding the
+// pattern with the start of the period, e.g. "/var/log/app-%Y-%m-%d.log",
+// and Filename is ignored. Times are in UTC unless LocalTime is set.
+//
+// With LevelFiles, the entries of each listed level go to their own file
+// rather than the main one, named by inserting the level before the
+// extension, e.g. app.error.log. If DuplicateToMain is set, those at or
+// above DuplicateLevel are also written to the main file.
+//
+// The entries are committed to stable storage according to FsyncEvery,
+// FsyncInterval and FsyncLevel, and on Sync. If MinFreeDisk is set, the
+// entries below LowDiskLevel are dropped while the free space of the file
+// system holding the log file is under it.
+//
+// With Audit, each file holds a hash chain of its entries, continued across
+// rotations and restarts.
 func NewFileLogger(oh ...OptionHandler) *FileLogger {
 
 	// initialize config
@@ -24,144 +50,142 @@ func NewFileLogger(oh ...OptionHandler) *FileLogger {
 	for _, fn := range oh {
 		fn(&opts)
 	}
-	// file rotate config
-	hook := lumberjack.Logger{
-		Filename:   opts.Filename,
-		MaxSize:    opts.MaxSize,
-		MaxAge:     opts.MaxAge,
-		MaxBackups: opts.MaxBackups,
-		Compress:   opts.Compress,
-		LocalTime:  opts.LocalTime,
-	}
-	write := zapcore.AddSync(&hook)
-
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        opts.TimeKey,
-		LevelKey:       opts.LevelKey,
-		NameKey:        opts.NameKey,
-		CallerKey:      opts.CallerKey,
-		MessageKey:     opts.MessageKey,
-		StacktraceKey:  opts.StacktraceKey,
-		SkipLineEnding: opts.SkipLineEnding,
-		LineEnding:     opts.LineEnding,
-		FunctionKey:    opts.FunctionKey,
-		EncodeLevel: func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
-			opts.EncodeLevel(l, enc)
-		},
-		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-			opts.EncodeTime(t, enc)
-		},
-		EncodeDuration: func(td time.Duration, enc zapcore.PrimitiveArrayEncoder) {
-			opts.EncodeDuration(td, enc)
-		},
-		EncodeCaller: func(ec zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
-			opts.EncodeCaller(EntryCaller(ec), enc)
-		},
-		EncodeName: func(name string, enc zapcore.PrimitiveArrayEncoder) {
-			opts.EncodeName(name, enc)
-		},
-		NewReflectedEncoder: func(w io.Writer) zapcore.ReflectedEncoder {
-			return opts.NewReflectedEncoder(w)
-		},
-		ConsoleSeparator: opts.ConsoleSeparator,
-	}
-	// level
-	atomicLevel := zap.NewAtomicLevel()
-	atomicLevel.SetLevel(opts.Level)
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		write,
-		atomicLevel)
-
-	zapOpts := make([]zap.Option, 0)
-	if opts.WithCaller {
-		zapOpts = append(zapOpts, zap.AddCaller())
-	}
-	if opts.WithStack {
-		zapOpts = append(zapOpts, zap.AddStacktrace(DebugLevel))
-	}
-
-	logger := zap.New(core, zapOpts...)
+	w := newRotatingWriter(opts)
+	var guard zapcore.LevelEnabler
+	if opts.MinFreeDisk > 0 {
+		guard = newLowDiskGuard(filepath.Dir(w.currentName(w.now())), opts.MinFreeDisk, opts.LowDiskLevel, w.clock)
+	}
+	if len(opts.LevelFiles) == 0 {
+		return &FileLogger{
+			zapLogger: newZapLoggerWithCore(opts, newFileCore(opts, w, guard)),
+			writers:   []*RotatingWriter{w},
+		}
+	}
 
+	split := make(map[Level]bool, len(opts.LevelFiles))
+	for _, lf := range opts.LevelFiles {
+		split[lf.Level] = true
+	}
+	toMain := zap.LevelEnablerFunc(func(l Level) bool {
+		return !split[l] || opts.DuplicateToMain && l >= opts.DuplicateLevel
+	})
+	writers := []*RotatingWriter{w}
+	cores := []zapcore.Core{newFileCore(opts, w, andEnablers(toMain, guard))}
+	for _, lf := range opts.LevelFiles {
+		lvl := lf.Level
+		lopts := levelFileOptions(opts, lf)
+		lw := newRotatingWriter(lopts)
+		writers = append(writers, lw)
+		cores = append(cores, newFileCore(lopts, lw, andEnablers(zap.LevelEnablerFunc(func(l Level) bool {
+			return l == lvl
+		}), guard)))
+	}
 	return &FileLogger{
-		zap:       logger,
-		extfields: opts.ExtFields,
+		zapLogger: newZapLoggerWithCore(opts, zapcore.NewTee(cores...)),
+		writers:   writers,
 	}
 }
 
-// Info logs a message at InfoLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (f *FileLogger) Info(ctx context.Context, msg string, fields ...Field) {
-	f.Log(ctx, InfoLevel, msg, fields...)
+// newFileCore build the core writing the entries enabled by the options
+// level and enab into w, chained if Audit is set, committing those at or
+// above FsyncLevel if FsyncOnLevel is set.
+func newFileCore(opts Options, w *RotatingWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	var out io.Writer = w
+	if opts.Audit {
+		opts.Encoding, opts.SkipLineEnding, opts.LineEnding = JSONEncoding, false, "\n"
+		out = newAuditWriter(w, opts)
+	}
+	core := newZapCore(opts, out, JSONEncoding, enab)
+	if opts.FsyncOnLevel {
+		core = &fsyncCore{Core: core, level: opts.FsyncLevel, w: w}
+	}
+	return core
 }
 
-// Debug logs a message at DebugLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (f *FileLogger) Debug(ctx context.Context, msg string, fields ...Field) {
-	f.Log(ctx, DebugLevel, msg, fields...)
+// fsyncCore commits w after writing the entries at or above level.
+type fsyncCore struct {
+	zapcore.Core
+	level Level
+	w     *RotatingWriter
 }
 
-// Warn logs a message at WarnLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (f *FileLogger) Warn(ctx context.Context, msg string, fields ...Field) {
-	f.Log(ctx, WarnLevel, msg, fields...)
+func (c *fsyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fsyncCore{Core: c.Core.With(fields), level: c.level, w: c.w}
 }
 
-// Error logs a message at ErrorLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (f *FileLogger) Error(ctx context.Context, msg string, fields ...Field) {
-	f.Log(ctx, ErrorLevel, msg, fields...)
+func (c *fsyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
 }
 
-// Panic logs a message at PanicLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-//
-// The logger then panics, even if logging at PanicLevel is disabled.
-func (f *FileLogger) Panic(ctx context.Context, msg string, fields ...Field) {
-	f.Log(ctx, PanicLevel, msg, fields...)
+func (c *fsyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(ent, fields); err != nil {
+		return err
+	}
+	if ent.Level >= c.level {
+		return c.w.Sync()
+	}
+	return nil
 }
 
-// Fatal logs a message at FatalLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-//
-// The logger then calls os.Exit(1), even if logging at FatalLevel is
-// disabled.
-func (f *FileLogger) Fatal(ctx context.Context, msg string, fields ...Field) {
-	f.Log(ctx, FatalLevel, msg, fields...)
+// levelFileOptions returns the options of the level file: the options of
+// the logger with the level inserted in the file names, overridden by the
+// options of the level file.
+func levelFileOptions(opts Options, lf LevelFile) Options {
+	lopts := opts
+	lopts.LevelFiles = nil
+	lopts.Filename = levelFilename(opts.Filename, lf.Level)
+	if opts.FilenamePattern != "" {
+		lopts.FilenamePattern = levelFilename(opts.FilenamePattern, lf.Level)
+	}
+	if opts.CurrentLink != "" {
+		lopts.CurrentLink = levelFilename(opts.CurrentLink, lf.Level)
+	}
+	for _, fn := range lf.Options {
+		fn(&lopts)
+	}
+	return lopts
 }
 
-// Log logs a message at the specified level. The message includes any fields
-// passed at the log site, as well as any fields accumulated on the logger.
-func (f *FileLogger) Log(ctx context.Context, lvl Level, msg string, fields ...Field) {
-	if f.zap == nil {
-		return
-	}
-	// static extend fields
-	fields = append(fields, f.extfields...)
+// levelFilename inserts the level name before the extension of name.
+func levelFilename(name string, lvl Level) string {
+	ext := filepath.Ext(name)
+	return name[:len(name)-len(ext)] + "." + lvl.String() + ext
+}
 
-	// context extend fields
-	cval := ctx.Value(ContextFieldsKey)
-	if cval != nil {
-		if cfields, ok := cval.([]Field); ok {
-			fields = append(fields, cfields...)
+// Rotate closes the log files and opens new ones.
+func (f *FileLogger) Rotate() error {
+	var firstErr error
+	for _, w := range f.writers {
+		if err := w.Rotate(); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
-	// write
-	if ce := f.zap.Check(lvl, msg); ce != nil {
-		if len(fields) == 0 {
-			ce.Write()
-			return
+	return firstErr
+}
+
+// Reopen closes the log files and opens them again by name, e.g. after
+// logrotate renamed them.
+func (f *FileLogger) Reopen() error {
+	var firstErr error
+	for _, w := range f.writers {
+		if err := w.Reopen(); err != nil && firstErr == nil {
+			firstErr = err
 		}
-		ce.Write(FieldsConvert(fields)...)
 	}
+	return firstErr
 }
 
-// Sync flushing any buffered log entries.
-//
-// Applications should take care to call Sync before exiting.
-func (f *FileLogger) Sync(ctx context.Context) {
-	if f.zap == nil {
-		return
-	}
+// Close flushes any buffered log entries, and closes the log files.
+func (f *FileLogger) Close() error {
 	f.zap.Sync()
+	var firstErr error
+	for _, w := range f.writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
@@ -0,0 +1,62 @@
+package log4go
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileLoggerSplitByLevel(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	flog := NewFileLogger(WithFileName(name), WithSplitByLevel(DebugLevel),
+		WithLevelFile(ErrorLevel, WithMaxSize(1)), WithDuplicateToMain(ErrorLevel))
+	ctx := context.TODO()
+	flog.Debug(ctx, "debug entry")
+	flog.Info(ctx, "info entry")
+	flog.Error(ctx, "error entry")
+	if err := flog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	main, debug, errs := read("app.log"), read("app.debug.log"), read("app.error.log")
+	if strings.Contains(main, "debug entry") || !strings.Contains(main, "info entry") || !strings.Contains(main, "error entry") {
+		t.Errorf("main file holds %s", main)
+	}
+	if !strings.Contains(debug, "debug entry") || strings.Count(debug, "\n") != 1 {
+		t.Errorf("debug file holds %s", debug)
+	}
+	if !strings.Contains(errs, "error entry") || strings.Count(errs, "\n") != 1 {
+		t.Errorf("error file holds %s", errs)
+	}
+}
+
+func TestFileLoggerLevelFileTransforms(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	flog := NewFileLogger(WithFileName(name), WithLevelFile(ErrorLevel), WithSanitize(true), WithStack(false))
+	ctx := context.TODO()
+	flog.Info(ctx, "info\nentry")
+	flog.Error(ctx, "error\nentry")
+	if err := flog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	main, _ := os.ReadFile(name)
+	errs, _ := os.ReadFile(filepath.Join(dir, "app.error.log"))
+	if !strings.Contains(string(main), `info\\nentry`) || strings.Contains(string(main), "error") {
+		t.Errorf("main file holds %s", main)
+	}
+	if !strings.Contains(string(errs), `error\\nentry`) || strings.Count(string(errs), "\n") != 1 {
+		t.Errorf("error file holds %s", errs)
+	}
+}
@@ -0,0 +1,122 @@
+package log4go
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"time"
+)
+
+// Clock tells the time to RotatingWriter, it can be replaced in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// File is a file opened by a FileSystem.
+type File interface {
+	io.ReadWriteCloser
+	Sync() error
+	Stat() (os.FileInfo, error)
+}
+
+// FileSystem is the file system RotatingWriter works on, it can be
+// replaced in tests. OSFileSystem is the default.
+type FileSystem interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	ReadDir(dir string) ([]os.FileInfo, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Symlink(oldname, newname string) error
+	Chown(name string, uid, gid int) error
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// OSFileSystem is the FileSystem of the operating system.
+type OSFileSystem struct{}
+
+func (OSFileSystem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (OSFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (OSFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (OSFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFileSystem) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		// skip the files removed meanwhile
+		if info, err := e.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OSFileSystem) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+func (OSFileSystem) Chown(name string, uid, gid int) error {
+	return os.Chown(name, uid, gid)
+}
+
+func (OSFileSystem) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// Compressor compresses the rotated log files, GzipCompressor or
+// ZstdCompressor. Other formats plug in by implementing it.
+type Compressor interface {
+	// Extension is appended to the names of the compressed files.
+	Extension() string
+	// NewWriter returns a writer compressing into w, flushed on Close.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// GzipCompressor compresses with gzip at Level, gzip.DefaultCompression
+// if zero.
+type GzipCompressor struct {
+	Level int
+}
+
+func (GzipCompressor) Extension() string {
+	return ".gz"
+}
+
+func (g GzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
@@ -0,0 +1,340 @@
+package log4go
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FluentMode is the Forward protocol mode used by FluentLogger.
+type FluentMode int
+
+const (
+	// FluentForward messages carry the entries of a tag as an array of
+	// [time, record] arrays.
+	FluentForward FluentMode = iota
+	// FluentPackedForward messages carry the entries of a tag as a binary
+	// string of concatenated [time, record] arrays.
+	FluentPackedForward
+)
+
+// DefaultFluentTag tags the entries of unnamed loggers when no
+// FluentTagPrefix is set.
+const DefaultFluentTag = "log4go"
+
+// fluentEntry is an encoded [time, record] array, with its tag.
+type fluentEntry struct {
+	tag  string
+	data []byte
+}
+
+// fluentCore encodes each entry as a MessagePack [time, record] array, the
+// record holding the message, level, logger name, caller, stack trace and
+// fields under the keys of the options.
+type fluentCore struct {
+	zapcore.LevelEnabler
+	cfg    *EncoderConfig
+	enc    *zapcore.MapObjectEncoder
+	writer *fluentWriter
+}
+
+func (c *fluentCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.cloneFields()
+	for i := range fields {
+		fields[i].AddTo(clone.enc)
+	}
+	return &clone
+}
+
+func (c *fluentCore) cloneFields() *zapcore.MapObjectEncoder {
+	enc := zapcore.NewMapObjectEncoder()
+	for k, v := range c.enc.Fields {
+		enc.Fields[k] = v
+	}
+	return enc
+}
+
+func (c *fluentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fluentCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := c.cloneFields()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	record := enc.Fields
+	if c.cfg.MessageKey != "" {
+		record[c.cfg.MessageKey] = ent.Message
+	}
+	if c.cfg.LevelKey != "" {
+		record[c.cfg.LevelKey] = ent.Level.String()
+	}
+	if ent.LoggerName != "" && c.cfg.NameKey != "" {
+		record[c.cfg.NameKey] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		if c.cfg.CallerKey != "" {
+			record[c.cfg.CallerKey] = ent.Caller.String()
+		}
+		if c.cfg.FunctionKey != "" && ent.Caller.Function != "" {
+			record[c.cfg.FunctionKey] = ent.Caller.Function
+		}
+	}
+	if ent.Stack != "" && c.cfg.StacktraceKey != "" {
+		record[c.cfg.StacktraceKey] = ent.Stack
+	}
+
+	data := appendMsgpackArrayHeader(nil, 2)
+	data = appendMsgpackEventTime(data, ent.Time)
+	data = appendMsgpackValue(data, c.cfg, record)
+	tag := c.writer.tag(ent.LoggerName)
+	c.writer.batcher.add(batchItem{payload: &fluentEntry{tag: tag, data: data}, size: len(tag) + len(data)})
+	if ent.Level > ErrorLevel {
+		// the process is about to exit
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync sends the waiting entries from the background goroutine, waiting for
+// them at most DialTimeout as zap holds its lock meanwhile.
+func (c *fluentCore) Sync() error {
+	return c.writer.batcher.sync(c.writer.syncTimeout)
+}
+
+// fluentWriter sends the batches to the Fluentd server, one message per
+// tag, waiting for the server to acknowledge each chunk if required.
+type fluentWriter struct {
+	network    string
+	address    string
+	options    Options
+	mode       FluentMode
+	ack        bool
+	prefix     string
+	batcher    *batcher
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	syncTimeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// tag returns the tag of the entries of the named logger.
+func (f *fluentWriter) tag(name string) string {
+	switch {
+	case name == "" && f.prefix == "":
+		return DefaultFluentTag
+	case name == "":
+		return f.prefix
+	case f.prefix == "":
+		return name
+	}
+	return f.prefix + "." + name
+}
+
+func (f *fluentWriter) send(items []batchItem) error {
+	var tags []string
+	entries := make(map[string][][]byte)
+	for _, item := range items {
+		e := item.payload.(*fluentEntry)
+		if _, ok := entries[e.tag]; !ok {
+			tags = append(tags, e.tag)
+		}
+		entries[e.tag] = append(entries[e.tag], e.data)
+	}
+	for _, tag := range tags {
+		if err := f.sendMessage(tag, entries[tag]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendMessage sends the entries of the tag in a single message, retrying
+// RetryMax times with exponential backoff on failure.
+func (f *fluentWriter) sendMessage(tag string, entries [][]byte) error {
+	msg := appendMsgpackArrayHeader(nil, 3)
+	msg = appendMsgpackString(msg, tag)
+	if f.mode == FluentPackedForward {
+		var packed []byte
+		for _, e := range entries {
+			packed = append(packed, e...)
+		}
+		msg = appendMsgpackBin(msg, packed)
+	} else {
+		msg = appendMsgpackArrayHeader(msg, len(entries))
+		for _, e := range entries {
+			msg = append(msg, e...)
+		}
+	}
+	var chunk string
+	if f.ack {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		msg = appendMsgpackMapHeader(msg, 2)
+		msg = appendMsgpackString(msg, "size")
+		msg = appendMsgpackUint(msg, uint64(len(entries)))
+		msg = appendMsgpackString(msg, "chunk")
+		msg = appendMsgpackString(msg, chunk)
+	} else {
+		msg = appendMsgpackMapHeader(msg, 1)
+		msg = appendMsgpackString(msg, "size")
+		msg = appendMsgpackUint(msg, uint64(len(entries)))
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = f.try(msg, chunk); err == nil || attempt >= f.options.RetryMax {
+			return err
+		}
+		f.batcher.countRetry()
+		time.Sleep(backoffDelay(attempt, f.minBackoff, f.maxBackoff))
+	}
+}
+
+// try sends the message once, and waits for the ack of the chunk if set.
+func (f *fluentWriter) try(msg []byte, chunk string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn == nil {
+		conn, err := dialNetwork(f.network, f.address, f.options.DialTimeout, f.options.TLSConfig)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+		f.reader = bufio.NewReader(conn)
+	}
+	err := f.write(msg, chunk)
+	if err != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+	return err
+}
+
+func (f *fluentWriter) write(msg []byte, chunk string) error {
+	if _, err := f.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	f.conn.SetReadDeadline(time.Now().Add(f.options.FluentAckTimeout))
+	defer f.conn.SetReadDeadline(time.Time{})
+	resp, err := readMsgpack(f.reader)
+	if err != nil {
+		return err
+	}
+	if m, ok := resp.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return fmt.Errorf("log4go: fluent ack for %s: unexpected response %v", chunk, resp)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (f *fluentWriter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conn == nil {
+		return nil
+	}
+	err := f.conn.Close()
+	f.conn = nil
+	return err
+}
+
+// FluentLogger logger sends entries to Fluentd or Fluent Bit over the
+// Forward protocol
+type FluentLogger struct {
+	zapLogger
+	writer *fluentWriter
+}
+
+// NewFluentLogger create a new FluentLogger which sends the entries to the
+// Forward input at the address on the network: "tcp", "unix", or "tls" for
+// the secure forward protocol.
+//
+// Entries are tagged with the logger name prefixed by FluentTagPrefix and a
+// dot, and batched as by HTTPLogger. Each batch is sent as one message per
+// tag in FluentMode, with the times as EventTime. If FluentAck is set, each
+// message carries a chunk id, and is sent again unless the server
+// acknowledges it within FluentAckTimeout.
+func NewFluentLogger(network, address string, oh ...OptionHandler) *FluentLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+
+	w := &fluentWriter{
+		network:    network,
+		address:    address,
+		options:    opts,
+		mode:       opts.FluentMode,
+		ack:        opts.FluentAck,
+		prefix:     opts.FluentTagPrefix,
+		minBackoff: opts.RetryBackoff,
+		maxBackoff: opts.RetryMaxBackoff,
+
+		syncTimeout: opts.DialTimeout,
+	}
+	if w.syncTimeout <= 0 {
+		w.syncTimeout = 5 * time.Second
+	}
+	if w.minBackoff <= 0 {
+		w.minBackoff = 500 * time.Millisecond
+	}
+	if w.maxBackoff < w.minBackoff {
+		w.maxBackoff = 30 * time.Second
+	}
+	w.batcher = newBatcher(opts, w.send)
+
+	cfg := newEncoderConfig(opts)
+	level := zap.NewAtomicLevel()
+	level.SetLevel(opts.Level)
+	core := &fluentCore{
+		LevelEnabler: level,
+		cfg:          &cfg,
+		enc:          zapcore.NewMapObjectEncoder(),
+		writer:       w,
+	}
+	return &FluentLogger{
+		zapLogger: newZapLoggerWithCore(opts, core),
+		writer:    w,
+	}
+}
+
+// Stats returns the number of entries sent, dropped and failed so far.
+func (f *FluentLogger) Stats() BatchStats {
+	return f.writer.batcher.Stats()
+}
+
+// Close sends the waiting entries, and closes the connection.
+func (f *FluentLogger) Close() error {
+	f.zap.Sync()
+	err := f.writer.batcher.close()
+	if cerr := f.writer.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
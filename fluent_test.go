@@ -0,0 +1,157 @@
+package log4go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	var b []byte
+	b = appendMsgpackValue(b, nil, map[string]interface{}{
+		"neg":   int64(-200000),
+		"small": int8(-3),
+		"big":   uint64(1 << 40),
+		"f":     1.5,
+		"s":     string(bytes.Repeat([]byte("x"), 300)),
+		"arr":   []interface{}{true, nil, "a"},
+	})
+	v, err := readMsgpack(bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.(map[string]interface{})
+	arr := m["arr"].([]interface{})
+	if m["neg"] != int64(-200000) || m["small"] != int64(-3) || m["big"] != uint64(1<<40) || m["f"] != 1.5 ||
+		len(m["s"].(string)) != 300 || len(arr) != 3 || arr[0] != true || arr[1] != nil || arr[2] != "a" {
+		t.Errorf("unexpected round trip %v", m)
+	}
+}
+
+// fluentServer accepts one connection and decodes the Forward messages,
+// acknowledging the chunks.
+func fluentServer(ln net.Listener, messages chan<- []interface{}) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		v, err := readMsgpack(r)
+		if err != nil {
+			close(messages)
+			return
+		}
+		msg := v.([]interface{})
+		if opt, ok := msg[len(msg)-1].(map[string]interface{}); ok && opt["chunk"] != nil {
+			ack := appendMsgpackMapHeader(nil, 1)
+			ack = appendMsgpackString(ack, "ack")
+			ack = appendMsgpackString(ack, opt["chunk"].(string))
+			conn.Write(ack)
+		}
+		messages <- msg
+	}
+}
+
+func TestFluentLoggerForward(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	messages := make(chan []interface{}, 10)
+	go fluentServer(ln, messages)
+
+	flog := NewFluentLogger("tcp", ln.Addr().String(), WithName("api"), WithFluentTagPrefix("app"),
+		WithFluentAck(true, time.Second), WithBatchInterval(time.Hour), WithStack(false))
+	flog.Info(context.TODO(), "hello", Int("n", 1))
+	flog.Warn(context.TODO(), "world")
+	if err := flog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-messages
+	if msg[0] != "app.api" {
+		t.Errorf("unexpected tag %v", msg[0])
+	}
+	entries := msg[1].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	entry := entries[0].([]interface{})
+	ts, ok := entry[0].(msgpackExt)
+	if !ok || ts.Type != 0 || len(ts.Data) != 8 {
+		t.Fatalf("unexpected event time %v", entry[0])
+	}
+	if sec := binary.BigEndian.Uint32(ts.Data); time.Since(time.Unix(int64(sec), 0)) > time.Minute {
+		t.Errorf("unexpected event time %d", sec)
+	}
+	record := entry[1].(map[string]interface{})
+	if record["msg"] != "hello" || record["level"] != "info" || record["n"] != int64(1) || record["name"] != "api" {
+		t.Errorf("unexpected record %v", record)
+	}
+	if opt := msg[2].(map[string]interface{}); opt["size"] != int64(2) {
+		t.Errorf("unexpected option %v", opt)
+	}
+	if stats := flog.Stats(); stats.Sent != 2 || stats.Failed != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestFluentLoggerSyncTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	flog := NewFluentLogger("tcp", addr, WithDialTimeout(50*time.Millisecond), WithRetry(5, 100*time.Millisecond, 100*time.Millisecond))
+	flog.Info(context.TODO(), "retried while the server is down")
+	start := time.Now()
+	if err := flog.zap.Sync(); err == nil {
+		t.Error("expected Sync to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("Sync blocked for %v", elapsed)
+	}
+	flog.Close()
+	if stats := flog.Stats(); stats.Failed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestFluentLoggerPackedForward(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	messages := make(chan []interface{}, 10)
+	go fluentServer(ln, messages)
+
+	flog := NewFluentLogger("tcp", ln.Addr().String(), WithFluentMode(FluentPackedForward), WithBatchInterval(time.Hour))
+	flog.Info(context.TODO(), "first")
+	flog.Info(context.TODO(), "second")
+	flog.Close()
+
+	msg := <-messages
+	if msg[0] != DefaultFluentTag {
+		t.Errorf("unexpected tag %v", msg[0])
+	}
+	r := bufio.NewReader(bytes.NewReader(msg[1].([]byte)))
+	for _, want := range []string{"first", "second"} {
+		v, err := readMsgpack(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record := v.([]interface{})[1].(map[string]interface{}); record["msg"] != want {
+			t.Errorf("unexpected record %v", record)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package log4go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// GELFEncoding writes each entry as a GELF 1.1 message.
+const GELFEncoding = "gelf"
+
+const (
+	// gelfChunkHeaderSize is the size of the magic bytes, message id, sequence
+	// number and sequence count heading each chunk.
+	gelfChunkHeaderSize = 12
+	// gelfMaxChunks is the most chunks a message may be split into.
+	gelfMaxChunks = 128
+	// DefaultGELFChunkSize is the size of UDP datagrams which fits in the
+	// MTU of most local networks.
+	DefaultGELFChunkSize = 1420
+)
+
+var (
+	_gelfPool = buffer.NewPool()
+
+	gelfKeyReplacer = regexp.MustCompile(`[^\w.\-]`)
+)
+
+// syslogSeverity maps a Level to the numeric severity defined by syslog,
+// which is also used by GELF.
+func syslogSeverity(l Level) int {
+	switch l {
+	case DebugLevel:
+		return 7
+	case InfoLevel:
+		return 6
+	case WarnLevel:
+		return 4
+	case ErrorLevel:
+		return 3
+	case FatalLevel:
+		return 1
+	default: // DPanic, Panic
+		return 2
+	}
+}
+
+// gelfEntryFields are the additional fields written from the entry.
+var gelfEntryFields = map[string]bool{
+	"_logger":   true,
+	"_file":     true,
+	"_line":     true,
+	"_function": true,
+}
+
+// NewGELFEncoder creates an encoder which writes each entry as a GELF 1.1
+// message. The first line of the message is written as short_message, the
+// whole message with the stack trace as full_message, and the fields as
+// additional fields prefixed with '_'. Nested objects are flattened to
+// dotted names, and values other than strings and numbers are written as
+// strings. The fields named id, logger, file, line or function are written
+// with a trailing '_', as _id_, so that they don't replace the fields of the
+// entry.
+func NewGELFEncoder(cfg EncoderConfig, host string) Encoder {
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	}
+	return &gelfEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              &cfg,
+		host:             host,
+	}
+}
+
+type gelfEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg  *EncoderConfig
+	host string
+}
+
+func (g *gelfEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range g.Fields {
+		clone.Fields[k] = v
+	}
+	return &gelfEncoder{MapObjectEncoder: clone, cfg: g.cfg, host: g.host}
+}
+
+func (g *gelfEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := g.Clone().(*gelfEncoder)
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+
+	msg := make(map[string]interface{}, len(enc.Fields)+10)
+	for k, v := range enc.Fields {
+		g.addField(msg, k, v)
+	}
+
+	short := ent.Message
+	if i := strings.IndexByte(short, '\n'); i >= 0 {
+		short = short[:i]
+	}
+	if strings.TrimSpace(short) == "" {
+		short = "-"
+	}
+	msg["version"] = "1.1"
+	msg["host"] = g.host
+	msg["short_message"] = short
+	msg["timestamp"] = math.Round(float64(ent.Time.UnixNano())/1e6) / 1e3
+	msg["level"] = syslogSeverity(ent.Level)
+	if full := ent.Message; full != short || ent.Stack != "" {
+		if ent.Stack != "" {
+			full += "\n" + ent.Stack
+		}
+		msg["full_message"] = full
+	}
+	if ent.LoggerName != "" {
+		msg["_logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		msg["_file"] = ent.Caller.File
+		msg["_line"] = ent.Caller.Line
+		if ent.Caller.Function != "" {
+			msg["_function"] = ent.Caller.Function
+		}
+	}
+
+	line := _gelfPool.Get()
+	jenc := json.NewEncoder(line)
+	jenc.SetEscapeHTML(false)
+	if err := jenc.Encode(msg); err != nil {
+		line.Free()
+		return nil, err
+	}
+	line.TrimNewline()
+	line.AppendString(g.cfg.LineEnding)
+	return line, nil
+}
+
+// addField adds the field as additional fields of the message, flattening
+// nested objects.
+func (g *gelfEncoder) addField(msg map[string]interface{}, key string, val interface{}) {
+	flattenField(key, val, func(key string, val interface{}) {
+		key = "_" + gelfKeyReplacer.ReplaceAllString(key, "_")
+		if key == "_id" || gelfEntryFields[key] {
+			// _id is reserved by GELF, the others are written from the
+			// entry
+			key += "_"
+		}
+		msg[key] = flatValue(g.cfg, val)
+	})
+}
+
+// flattenField calls fn for the field, or for each of its nested fields
+// with dotted keys if it is an object.
+func flattenField(key string, val interface{}, fn func(key string, val interface{})) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		fn(key, val)
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		flattenField(key+"."+k, m[k], fn)
+	}
+}
+
+// flatValue converts a value collected by a MapObjectEncoder to a string or
+// a number.
+func flatValue(cfg *EncoderConfig, val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		return v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return v
+	case float32:
+		return flatValue(cfg, float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatFloat(v, 64)
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		if cfg.EncodeDuration == nil {
+			return int64(v)
+		}
+		arr := &logfmtArrayEncoder{cfg: cfg, buf: _gelfPool.Get()}
+		defer arr.buf.Free()
+		cfg.EncodeDuration(v, arr)
+		if f, err := strconv.ParseFloat(arr.buf.String(), 64); err == nil {
+			return f
+		}
+		return arr.buf.String()
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(b)
+}
+
+// gelfWriter ships the GELF messages, one per write, chunked and
+// optionally compressed over UDP, or delimited over TCP.
+type gelfWriter struct {
+	network    string
+	address    string
+	compress   bool
+	chunkSize  int
+	delimiter  byte
+	timeout    time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	failures int
+	nextDial time.Time
+	dialErr  error
+}
+
+func (g *gelfWriter) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.connect(); err != nil {
+		return 0, err
+	}
+	var err error
+	if strings.HasPrefix(g.network, "udp") {
+		err = g.writeUDP(p)
+	} else {
+		err = g.writeStream(p)
+	}
+	if err != nil {
+		// reconnect on the next write
+		g.conn.Close()
+		g.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// connect establishes the connection unless it is up, or returns the last
+// dial error while the backoff delay isn't over.
+func (g *gelfWriter) connect() error {
+	if g.conn != nil {
+		return nil
+	}
+	if time.Now().Before(g.nextDial) {
+		return g.dialErr
+	}
+	conn, err := dialNetwork(g.network, g.address, g.timeout, nil)
+	if err != nil {
+		g.nextDial = time.Now().Add(backoffDelay(g.failures, g.minBackoff, g.maxBackoff))
+		g.failures++
+		g.dialErr = err
+		return err
+	}
+	g.conn = conn
+	g.failures = 0
+	return nil
+}
+
+// writeStream writes the message followed by the delimiter, as GELF over
+// TCP doesn't support compression.
+func (g *gelfWriter) writeStream(p []byte) error {
+	buf := _gelfPool.Get()
+	defer buf.Free()
+	buf.Write(p)
+	buf.AppendByte(g.delimiter)
+	_, err := g.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeUDP writes the message in a single datagram, or split in chunks if
+// it doesn't fit.
+func (g *gelfWriter) writeUDP(p []byte) error {
+	if g.compress {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		if _, err := zw.Write(p); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		p = zbuf.Bytes()
+	}
+	if len(p) <= g.chunkSize {
+		_, err := g.conn.Write(p)
+		return err
+	}
+
+	size := g.chunkSize - gelfChunkHeaderSize
+	count := (len(p) + size - 1) / size
+	if count > gelfMaxChunks {
+		return fmt.Errorf("log4go: gelf message of %d bytes needs more than %d chunks", len(p), gelfMaxChunks)
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, g.chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(p) {
+			end = len(p)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, p[i*size:end]...)
+		if _, err := g.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *gelfWriter) Sync() error {
+	return nil
+}
+
+// Close closes the connection.
+func (g *gelfWriter) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
+
+// GELFLogger logger ships GELF messages to Graylog over UDP or TCP
+type GELFLogger struct {
+	zapLogger
+	writer *gelfWriter
+}
+
+// NewGELFLogger create a new GELFLogger which sends the entries to the
+// address on the network, "udp" or "tcp". Over UDP, messages are gzip
+// compressed if Compress is set, and split in chunks of GELFChunkSize bytes.
+// Over TCP, messages are delimited by GELFDelimiter, a null byte by default.
+//
+// The connection is established on the first entry, and established again
+// after a failed write. A failed dial is retried after RetryBackoff, doubled
+// after each failure up to RetryMaxBackoff.
+func NewGELFLogger(network, address string, oh ...OptionHandler) *GELFLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	opts.Compress = true
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	opts.Encoding = GELFEncoding
+	opts.SkipLineEnding = true
+
+	chunkSize := opts.GELFChunkSize
+	if chunkSize <= gelfChunkHeaderSize {
+		chunkSize = DefaultGELFChunkSize
+	}
+	w := &gelfWriter{
+		network:    network,
+		address:    address,
+		compress:   opts.Compress,
+		chunkSize:  chunkSize,
+		delimiter:  opts.GELFDelimiter,
+		timeout:    opts.DialTimeout,
+		minBackoff: opts.RetryBackoff,
+		maxBackoff: opts.RetryMaxBackoff,
+	}
+	return &GELFLogger{
+		zapLogger: newZapLogger(opts, w, GELFEncoding),
+		writer:    w,
+	}
+}
+
+// Close flushes any buffered log entries, and closes the connection.
+func (g *GELFLogger) Close() error {
+	g.zap.Sync()
+	return g.writer.Close()
+}
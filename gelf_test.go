@@ -0,0 +1,128 @@
+package log4go
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readGELFDatagram(t *testing.T, conn net.PacketConn) []byte {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read datagram: %v", err)
+	}
+	return buf[:n]
+}
+
+func TestGELFLoggerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.TODO()
+	glog := NewGELFLogger("udp", conn.LocalAddr().String(), WithHostname("h1"), WithStack(false))
+	defer glog.Close()
+
+	// compressed, single datagram
+	glog.Warn(ctx, "first line\nsecond line", String("id", "x"), Int("n", 1), String("file", "user.go"))
+	zr, err := gzip.NewReader(bytes.NewReader(readGELFDatagram(t, conn)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(zr)
+	var msg map[string]interface{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		t.Fatalf("unmarshal %q: %v", raw, err)
+	}
+	file, _ := msg["_file"].(string)
+	if msg["version"] != "1.1" || msg["host"] != "h1" || msg["level"] != float64(4) ||
+		msg["short_message"] != "first line" || msg["full_message"] != "first line\nsecond line" ||
+		msg["_id_"] != "x" || msg["_n"] != float64(1) || msg["_file_"] != "user.go" ||
+		!strings.HasSuffix(file, "gelf_test.go") {
+		t.Errorf("unexpected gelf message %s", raw)
+	}
+
+	// uncompressed, chunked
+	clog := NewGELFLogger("udp", conn.LocalAddr().String(), WithCompress(false), WithGELFChunkSize(100))
+	defer clog.Close()
+	long := strings.Repeat("x", 500)
+	clog.Info(ctx, long)
+
+	var count int
+	parts := map[byte][]byte{}
+	for count == 0 || len(parts) < count {
+		chunk := readGELFDatagram(t, conn)
+		if chunk[0] != 0x1e || chunk[1] != 0x0f || len(chunk) > 100 {
+			t.Fatalf("bad chunk header % x", chunk[:12])
+		}
+		count = int(chunk[11])
+		parts[chunk[10]] = chunk[12:]
+	}
+	var whole []byte
+	for i := 0; i < count; i++ {
+		whole = append(whole, parts[byte(i)]...)
+	}
+	msg = nil
+	if err := json.Unmarshal(whole, &msg); err != nil || msg["short_message"] != long {
+		t.Errorf("reassembled chunks %q: %v", whole, err)
+	}
+}
+
+func TestGELFLoggerTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	glog := NewGELFLogger("tcp", ln.Addr().String())
+	defer glog.Close()
+	glog.Info(context.TODO(), "one")
+	glog.Info(context.TODO(), "two")
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"one", "two"} {
+		raw, err := r.ReadBytes(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(raw[:len(raw)-1], &msg); err != nil || msg["short_message"] != want {
+			t.Errorf("unexpected message %q: %v", raw, err)
+		}
+	}
+}
+
+func TestGELFWriterDialBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := &gelfWriter{network: "tcp", address: addr, timeout: time.Second, minBackoff: time.Minute, maxBackoff: time.Minute}
+	if _, err := w.Write([]byte("{}")); err == nil {
+		t.Fatal("expected a dial error")
+	}
+	if _, err := w.Write([]byte("{}")); err == nil || w.failures != 1 {
+		t.Errorf("expected the dial to wait for the backoff, got %d failures, error %v", w.failures, err)
+	}
+}
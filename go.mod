@@ -3,6 +3,5 @@ module github.com/rumis/log4go
 go 1.16
 
 require (
-	github.com/natefinch/lumberjack v2.0.0+incompatible // indirect
 	go.uber.org/zap v1.23.0 // indirect
 )
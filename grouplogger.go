@@ -75,3 +75,8 @@ func (g *GroupLogger) Sync(ctx context.Context) {
 		l.Sync(ctx)
 	}
 }
+
+// Reopen reopens the files of the loggers which support it.
+func (g *GroupLogger) Reopen() error {
+	return reopenAll(g.loggers)
+}
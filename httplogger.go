@@ -0,0 +1,112 @@
+package log4go
+
+import (
+	"bytes"
+	"time"
+)
+
+// BatchFormat is the layout of the batches posted by HTTPLogger.
+type BatchFormat int
+
+const (
+	// NDJSON bodies hold one entry per line.
+	NDJSON BatchFormat = iota
+	// JSONArray bodies hold the entries as the elements of a JSON array.
+	JSONArray
+)
+
+// httpWriter queues each entry in a batcher, which posts them from a
+// background goroutine.
+type httpWriter struct {
+	batcher     *batcher
+	poster      *httpPoster
+	format      BatchFormat
+	syncTimeout time.Duration
+}
+
+func newHTTPWriter(url string, opts Options) *httpWriter {
+	w := &httpWriter{format: opts.BatchFormat, syncTimeout: opts.DialTimeout}
+	if w.syncTimeout <= 0 {
+		w.syncTimeout = 5 * time.Second
+	}
+	w.batcher = newBatcher(opts, w.send)
+	w.poster = newHTTPPoster(url, opts, &w.batcher.stats)
+	return w
+}
+
+func (h *httpWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(bytes.TrimRight(p, "\r\n")))
+	copy(entry, p)
+	h.batcher.add(batchItem{payload: entry, size: len(entry)})
+	return len(p), nil
+}
+
+func (h *httpWriter) send(items []batchItem) error {
+	var body bytes.Buffer
+	contentType := "application/x-ndjson"
+	if h.format == JSONArray {
+		contentType = "application/json"
+		body.WriteByte('[')
+	}
+	for i, item := range items {
+		if h.format == JSONArray && i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(item.payload.([]byte))
+		if h.format == NDJSON {
+			body.WriteByte('\n')
+		}
+	}
+	if h.format == JSONArray {
+		body.WriteByte(']')
+	}
+	return h.poster.post(body.Bytes(), contentType)
+}
+
+// Sync posts the waiting entries from the background goroutine, waiting for
+// them at most DialTimeout as zap holds its lock meanwhile.
+func (h *httpWriter) Sync() error {
+	return h.batcher.sync(h.syncTimeout)
+}
+
+// HTTPLogger logger posts batches of entries to an HTTP endpoint
+type HTTPLogger struct {
+	zapLogger
+	writer *httpWriter
+}
+
+// NewHTTPLogger create a new HTTPLogger which posts the json encoded entries
+// to url, in batches of up to BatchSize entries sent at least every
+// BatchInterval, as NDJSON or a JSON array according to BatchFormat. Bodies
+// are gzip compressed if Compress is set.
+//
+// Failed requests are retried RetryMax times with exponential backoff, on
+// network errors and 429 and 5xx responses. While the endpoint is
+// unreachable, entries are kept up to BatchMaxBytes, further entries are
+// dropped and counted in Stats, and Sync returns after DialTimeout.
+func NewHTTPLogger(url string, oh ...OptionHandler) *HTTPLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	opts.SkipLineEnding = true
+
+	w := newHTTPWriter(url, opts)
+	return &HTTPLogger{
+		zapLogger: newZapLogger(opts, w, JSONEncoding),
+		writer:    w,
+	}
+}
+
+// Stats returns the number of entries sent, dropped and failed so far.
+func (h *HTTPLogger) Stats() BatchStats {
+	return h.writer.batcher.Stats()
+}
+
+// Close posts the waiting entries, and stops the background goroutine.
+func (h *HTTPLogger) Close() error {
+	h.zap.Sync()
+	return h.writer.batcher.close()
+}
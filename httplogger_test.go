@@ -0,0 +1,132 @@
+package log4go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPLoggerNDJSON(t *testing.T) {
+	var mu sync.Mutex
+	var lines [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Content-Encoding") != "gzip" ||
+			r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		body, _ := io.ReadAll(zr)
+		mu.Lock()
+		lines = append(lines, bytes.Split(bytes.TrimSuffix(body, []byte("\n")), []byte("\n"))...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	hlog := NewHTTPLogger(srv.URL, WithCompress(true), WithHTTPHeader("Authorization", "Bearer token"),
+		WithBatchSize(2), WithBatchInterval(time.Hour))
+	for i := 0; i < 5; i++ {
+		hlog.Info(context.TODO(), "hello", Int("i", i))
+	}
+	if err := hlog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lines) != 5 {
+		t.Fatalf("got %d entries, want 5", len(lines))
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(lines[4], &entry); err != nil {
+		t.Fatalf("unmarshal %q: %v", lines[4], err)
+	}
+	if entry["msg"] != "hello" || entry["i"] != float64(4) {
+		t.Errorf("unexpected entry %s", lines[4])
+	}
+	if stats := hlog.Stats(); stats.Sent != 5 || stats.Dropped != 0 || stats.Failed != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHTTPLoggerRetry(t *testing.T) {
+	var calls int32
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	hlog := NewHTTPLogger(srv.URL, WithBatchFormat(JSONArray), WithRetry(5, time.Millisecond, 10*time.Millisecond))
+	hlog.Info(context.TODO(), "first")
+	hlog.Warn(context.TODO(), "second")
+	if err := hlog.writer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	hlog.Close()
+
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		t.Fatalf("unmarshal %q: %v", body, err)
+	}
+	if len(entries) != 2 || entries[1]["msg"] != "second" {
+		t.Errorf("unexpected body %s", body)
+	}
+	if stats := hlog.Stats(); stats.Retries != 2 || stats.Sent != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHTTPLoggerDrop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	hlog := NewHTTPLogger(srv.URL, WithBatchMaxBytes(300), WithBatchInterval(time.Hour), WithCaller(false), WithStack(false))
+	for i := 0; i < 10; i++ {
+		hlog.Info(context.TODO(), "dropped when the buffer is full")
+	}
+	if err := hlog.writer.Sync(); err == nil {
+		t.Error("expected the 400 response to fail the batch")
+	}
+	hlog.Close()
+	stats := hlog.Stats()
+	if stats.Dropped == 0 || stats.Failed == 0 || stats.Dropped+stats.Failed != 10 || stats.Retries != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHTTPLoggerSyncTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	hlog := NewHTTPLogger(srv.URL, WithDialTimeout(50*time.Millisecond), WithRetry(5, 100*time.Millisecond, 100*time.Millisecond))
+	hlog.Info(context.TODO(), "retried while the endpoint is down")
+	start := time.Now()
+	if err := hlog.writer.Sync(); err == nil {
+		t.Error("expected Sync to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("Sync blocked for %v", elapsed)
+	}
+	hlog.Close()
+	if stats := hlog.Stats(); stats.Failed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,241 @@
+package log4go
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// JournaldEncoding writes each entry in the native protocol of the systemd
+// journal.
+const JournaldEncoding = "journald"
+
+// DefaultJournaldSocket is the socket journald listens on for the native
+// protocol.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journalEntryFields are the names of the fields written from the entry, the
+// fields of the same name are prefixed by "F_".
+var journalEntryFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+var _journaldPool = buffer.NewPool()
+
+// NewJournaldEncoder creates an encoder which writes each entry as the
+// fields of a journal entry: the message as MESSAGE, the level as the
+// syslog PRIORITY, the caller as CODE_FILE, CODE_LINE and CODE_FUNC, the app
+// name as SYSLOG_IDENTIFIER, and each field under its key upper-cased, with
+// nested objects flattened and the characters not allowed in journal field
+// names replaced by '_'. Fields named as the fields of the entry, such as
+// "message", are prefixed by "F_".
+func NewJournaldEncoder(cfg EncoderConfig, identifier string) Encoder {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	return &journaldEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              &cfg,
+		identifier:       identifier,
+	}
+}
+
+type journaldEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg        *EncoderConfig
+	identifier string
+}
+
+func (j *journaldEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range j.Fields {
+		clone.Fields[k] = v
+	}
+	return &journaldEncoder{MapObjectEncoder: clone, cfg: j.cfg, identifier: j.identifier}
+}
+
+func (j *journaldEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := j.Clone().(*journaldEncoder)
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+
+	buf := _journaldPool.Get()
+	writeJournalField(buf, "MESSAGE", ent.Message)
+	writeJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(ent.Level)))
+	writeJournalField(buf, "SYSLOG_IDENTIFIER", j.identifier)
+	if ent.Caller.Defined {
+		writeJournalField(buf, "CODE_FILE", ent.Caller.File)
+		writeJournalField(buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			writeJournalField(buf, "CODE_FUNC", ent.Caller.Function)
+		}
+	}
+	if ent.LoggerName != "" && j.cfg.NameKey != "" {
+		writeJournalField(buf, journalFieldName(j.cfg.NameKey), ent.LoggerName)
+	}
+	if ent.Stack != "" && j.cfg.StacktraceKey != "" {
+		writeJournalField(buf, journalFieldName(j.cfg.StacktraceKey), ent.Stack)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		flattenField(k, enc.Fields[k], func(key string, val interface{}) {
+			name := journalFieldName(key)
+			if journalEntryFields[name] {
+				name = "F_" + name
+			}
+			writeJournalField(buf, name, fmt.Sprint(flatValue(j.cfg, val)))
+		})
+	}
+	return buf, nil
+}
+
+// journalFieldName returns the key upper-cased, with the characters other
+// than letters, digits and '_' replaced by '_'. Leading underscores, which
+// mark the fields trusted by journald, are removed, and names starting with
+// a digit are prefixed by "F_".
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	name := strings.TrimLeft(string(b), "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// writeJournalField writes NAME=value, or the name followed by the little
+// endian size and the value if the value holds a newline.
+func writeJournalField(buf *buffer.Buffer, name, val string) {
+	buf.AppendString(name)
+	if strings.IndexByte(val, '\n') < 0 {
+		buf.AppendByte('=')
+		buf.AppendString(val)
+		buf.AppendByte('\n')
+		return
+	}
+	buf.AppendByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(val)))
+	buf.Write(size[:])
+	buf.AppendString(val)
+	buf.AppendByte('\n')
+}
+
+// journaldWriter sends each entry in a datagram to journald. Entries too
+// large for a datagram are written to a deleted temporary file, whose
+// descriptor is passed to journald instead.
+type journaldWriter struct {
+	addr *net.UnixAddr
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+func (j *journaldWriter) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+		if err != nil {
+			return 0, err
+		}
+		j.conn = conn
+	}
+	_, _, err := j.conn.WriteMsgUnix(p, nil, j.addr)
+	if err == nil {
+		return len(p), nil
+	}
+	if !isJournalSizeError(err) {
+		return 0, err
+	}
+	if err := sendJournalFD(j.conn, j.addr, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (j *journaldWriter) Sync() error {
+	return nil
+}
+
+// Close closes the socket.
+func (j *journaldWriter) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.conn == nil {
+		return nil
+	}
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
+
+// JournaldLogger logger sends entries to the systemd journal
+type JournaldLogger struct {
+	zapLogger
+	writer *journaldWriter
+}
+
+// NewJournaldLogger create a new JournaldLogger which sends entries to the
+// journald native protocol socket at address, DefaultJournaldSocket if
+// empty. The app name is sent as SYSLOG_IDENTIFIER.
+func NewJournaldLogger(address string, oh ...OptionHandler) *JournaldLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	opts.Encoding = JournaldEncoding
+
+	if address == "" {
+		address = DefaultJournaldSocket
+	}
+	w := &journaldWriter{
+		addr: &net.UnixAddr{Name: address, Net: "unixgram"},
+	}
+	return &JournaldLogger{
+		zapLogger: newZapLogger(opts, w, JournaldEncoding),
+		writer:    w,
+	}
+}
+
+// Close flushes any buffered log entries, and closes the socket.
+func (j *JournaldLogger) Close() error {
+	j.zap.Sync()
+	return j.writer.Close()
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package log4go
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// sendJournalFD writes the entry into a deleted temporary file, preferably
+// in memory under /dev/shm, and passes its descriptor to journald.
+func sendJournalFD(conn *net.UnixConn, addr *net.UnixAddr, p []byte) error {
+	f, err := os.CreateTemp("/dev/shm", "log4go-journal-")
+	if err != nil {
+		if f, err = os.CreateTemp("", "log4go-journal-"); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+	// journald only accepts files which are no longer linked
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), addr)
+	return err
+}
+
+// isJournalSizeError reports whether the datagram was refused for its size.
+func isJournalSizeError(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
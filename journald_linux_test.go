@@ -0,0 +1,99 @@
+//go:build linux
+// +build linux
+
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// parseJournalEntry decodes the fields of a native protocol entry.
+func parseJournalEntry(t *testing.T, p []byte) map[string]string {
+	fields := map[string]string{}
+	for len(p) > 0 {
+		i := bytes.IndexAny(p, "=\n")
+		if i < 0 {
+			t.Fatalf("truncated entry %q", p)
+		}
+		name := string(p[:i])
+		if p[i] == '=' {
+			end := bytes.IndexByte(p[i:], '\n') + i
+			fields[name] = string(p[i+1 : end])
+			p = p[end+1:]
+			continue
+		}
+		size := int(binary.LittleEndian.Uint64(p[i+1 : i+9]))
+		fields[name] = string(p[i+9 : i+9+size])
+		p = p[i+9+size+1:]
+	}
+	return fields
+}
+
+func TestJournaldLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	jlog := NewJournaldLogger(path, WithAppName("app"), WithStack(false))
+	defer jlog.Close()
+
+	ctx := context.TODO()
+	jlog.Warn(ctx, "multi\nline", String("request.id", "r1"), Int("_n", 1), String("message", "user"))
+
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournalEntry(t, buf[:n])
+	if fields["MESSAGE"] != "multi\nline" || fields["PRIORITY"] != "4" || fields["SYSLOG_IDENTIFIER"] != "app" ||
+		fields["REQUEST_ID"] != "r1" || fields["N"] != "1" || fields["F_MESSAGE"] != "user" || fields["CODE_LINE"] == "" ||
+		!strings.HasSuffix(fields["CODE_FILE"], "journald_linux_test.go") {
+		t.Errorf("unexpected journal entry %q", fields)
+	}
+
+	// too large for a datagram, passed as a file descriptor
+	large := strings.Repeat("x", 4<<20)
+	jlog.Info(ctx, large)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("expect an empty datagram carrying the descriptor, got %d bytes", n)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("parse control message: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("parse unix rights: %v", err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+	f.Seek(0, io.SeekStart)
+	p, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := parseJournalEntry(t, p); fields["MESSAGE"] != large {
+		t.Errorf("unexpected large entry of %d bytes", len(fields["MESSAGE"]))
+	}
+}
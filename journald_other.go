@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package log4go
+
+import (
+	"errors"
+	"net"
+)
+
+// sendJournalFD is only supported on linux, where journald runs.
+func sendJournalFD(conn *net.UnixConn, addr *net.UnixAddr, p []byte) error {
+	return errors.New("log4go: journal entry too large for a datagram")
+}
+
+// isJournalSizeError reports false, as large entries can't be passed as
+// descriptors.
+func isJournalSizeError(err error) bool {
+	return false
+}
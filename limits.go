@@ -0,0 +1,163 @@
+package log4go
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// truncatedFieldsKey is the key of the number of fields dropped by
+// MaxFields.
+const truncatedFieldsKey = "truncated_fields"
+
+// limiter truncates the entries beyond the size limits of the options,
+// counting the entries truncated.
+type limiter struct {
+	maxMessage int
+	maxField   int
+	maxFields  int
+	maxEntry   int
+	count      *uint64
+	// enc measures the entries, as encoded in JSON
+	enc zapcore.Encoder
+}
+
+func newLimiter(opts Options, count *uint64) *limiter {
+	return &limiter{
+		maxMessage: opts.MaxMessageLength,
+		maxField:   opts.MaxFieldLength,
+		maxFields:  opts.MaxFields,
+		maxEntry:   opts.MaxEntrySize,
+		count:      count,
+		enc: zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			MessageKey:     "msg",
+			LevelKey:       "level",
+			TimeKey:        "time",
+			NameKey:        "name",
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+		}),
+	}
+}
+
+func (l *limiter) transform(ent *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	truncated := false
+	if l.maxMessage > 0 && len(ent.Message) > l.maxMessage {
+		ent.Message = truncateString(ent.Message, l.maxMessage)
+		truncated = true
+	}
+	if l.maxFields > 0 && len(fields) > l.maxFields {
+		dropped := len(fields) - l.maxFields
+		fields = append(fields[:l.maxFields], zap.Int(truncatedFieldsKey, dropped))
+		truncated = true
+	}
+	if l.maxField > 0 {
+		for i, f := range fields {
+			if s, ok := stringField(f); ok && len(s) > l.maxField {
+				fields[i] = zap.String(f.Key, truncateString(s, l.maxField))
+				truncated = true
+			}
+		}
+	}
+	if l.maxEntry > 0 && l.shrink(ent, fields) {
+		truncated = true
+	}
+	if truncated {
+		atomic.AddUint64(l.count, 1)
+	}
+	return fields
+}
+
+// shrink truncates the largest of the message and the field values until
+// the entry fits in maxEntry, reporting whether it truncated any.
+func (l *limiter) shrink(ent *zapcore.Entry, fields []zapcore.Field) bool {
+	excess := l.size(*ent, fields) - l.maxEntry
+	if excess <= 0 {
+		return false
+	}
+	for i, f := range fields {
+		if s, ok := stringField(f); ok && f.Type != zapcore.StringType {
+			fields[i] = zap.String(f.Key, s)
+		}
+	}
+	// each round truncates a value, and leaves at most its marker
+	for round := 0; excess > 0 && round <= len(fields); round++ {
+		largest, size := -1, len(ent.Message)
+		for i, f := range fields {
+			if f.Type == zapcore.StringType && len(f.String) > size {
+				largest, size = i, len(f.String)
+			}
+		}
+		// room for the marker and the escaping of the kept part
+		keep := size - excess - 32
+		if keep < 0 {
+			keep = 0
+		}
+		if largest < 0 {
+			ent.Message = truncateString(ent.Message, keep)
+		} else {
+			fields[largest].String = truncateString(fields[largest].String, keep)
+		}
+		excess = l.size(*ent, fields) - l.maxEntry
+	}
+	return true
+}
+
+// size returns the size of the entry encoded in JSON, less its stack
+// trace which isn't truncated.
+func (l *limiter) size(ent zapcore.Entry, fields []zapcore.Field) int {
+	ent.Stack = ""
+	buf, err := l.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return 0
+	}
+	defer buf.Free()
+	return buf.Len()
+}
+
+// stringField returns the value of f as a string, for the fields which may
+// be long: strings, byte strings, errors, stringers, objects, arrays and
+// reflected values, the latter as JSON.
+func stringField(f zapcore.Field) (string, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String, true
+	case zapcore.ByteStringType, zapcore.ErrorType, zapcore.StringerType,
+		zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		return stringValue(fieldValue(f)), true
+	}
+	return "", false
+}
+
+// truncateString cuts s to at most max bytes on a character boundary, then
+// appends a marker of the size cut, e.g. "…[truncated 1.2MB]".
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…[truncated " + formatBytes(len(s)-n) + "]"
+}
+
+// formatBytes formats n bytes in B, KB, MB or GB.
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	v, suffix := float64(n)/unit, "KB"
+	for _, s := range []string{"MB", "GB"} {
+		if v < unit {
+			break
+		}
+		v, suffix = v/unit, s
+	}
+	return fmt.Sprintf("%.1f%s", v, suffix)
+}
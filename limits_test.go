@@ -0,0 +1,76 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTruncateString(t *testing.T) {
+	if got := truncateString(strings.Repeat("a", 1300000), 10); got != "aaaaaaaaaa…[truncated 1.2MB]" {
+		t.Errorf("unexpected truncation %q", got)
+	}
+	// the cut falls on a character boundary
+	if got := truncateString("ééé", 3); got != "é…[truncated 4B]" {
+		t.Errorf("unexpected truncation %q", got)
+	}
+	if got := truncateString("short", 10); got != "short" {
+		t.Errorf("unexpected truncation %q", got)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	var buf bytes.Buffer
+	wlog := NewWriterLogger(&buf, WithStack(false), WithMaxMessageLength(5), WithMaxFieldLength(8), WithMaxFields(2))
+	wlog.Info(context.TODO(), "a long message", String("a", "0123456789"), ByteString("b", []byte("ok")), Int("c", 1))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "a lon…[truncated 9B]" || entry["a"] != "01234567…[truncated 2B]" || entry["b"] != "ok" ||
+		entry["c"] != nil || entry[truncatedFieldsKey] != 1.0 {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	// the entry size applies to the console encoding alike
+	buf.Reset()
+	clog := NewWriterLogger(&buf, WithEncoding(ConsoleEncoding), WithStack(false), WithMaxEntrySize(300))
+	clog.Info(context.TODO(), "big", String("small", "keep"), ByteString("blob", bytes.Repeat([]byte("x"), 1<<20)))
+	clog.Info(context.TODO(), "fits", String("small", "keep"))
+	if out := buf.String(); len(out) > 600 || !strings.Contains(out, "keep") || !strings.Contains(out, "…[truncated 1023.") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if n := clog.Truncations(); n != 1 {
+		t.Errorf("%d truncations, want 1", n)
+	}
+	if n := wlog.Truncations(); n != 1 {
+		t.Errorf("%d truncations, want 1", n)
+	}
+}
+
+func TestLimitsWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	count := new(uint64)
+	opts := DefaultOption()
+	opts.MaxFields = 3
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	core := newTransformCore(zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.DebugLevel), fieldTransforms(opts, count))
+	logger := zap.New(core).With(zap.Int("a", 1), zap.Int("b", 2))
+	if *count != 0 {
+		t.Errorf("%d truncations counted by With", *count)
+	}
+	logger.Info("m", zap.Int("c", 3), zap.Int("d", 4))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["a"] != 1.0 || entry["c"] != 3.0 || entry["d"] != nil || entry[truncatedFieldsKey] != 1.0 || *count != 1 {
+		t.Errorf("unexpected entry %v, %d truncations", entry, *count)
+	}
+}
@@ -0,0 +1,480 @@
+package log4go
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogfmtEncoding writes each entry as a line of space separated key=value
+// pairs, with nested objects flattened to dotted keys.
+const LogfmtEncoding = "logfmt"
+
+var _logfmtPool = buffer.NewPool()
+
+// NewLogfmtEncoder creates an encoder which writes each entry as a logfmt
+// line, such as
+//
+//	time="2006-01-02 15:04:05" level=info msg="request done" status=200
+//
+// Values holding spaces, quotes, '=' or control characters are quoted and
+// escaped, and invalid UTF-8 is replaced by U+FFFD. Fields of nested
+// objects and namespaces are flattened to dotted keys.
+func NewLogfmtEncoder(cfg EncoderConfig) Encoder {
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	}
+	return &logfmtEncoder{
+		cfg: &cfg,
+		buf: _logfmtPool.Get(),
+	}
+}
+
+type logfmtEncoder struct {
+	cfg *EncoderConfig
+	buf *buffer.Buffer
+	// prefix of the keys in the current namespace, such as "req.header."
+	prefix string
+}
+
+func (l *logfmtEncoder) clone() *logfmtEncoder {
+	buf := _logfmtPool.Get()
+	buf.Write(l.buf.Bytes())
+	return &logfmtEncoder{cfg: l.cfg, buf: buf, prefix: l.prefix}
+}
+
+func (l *logfmtEncoder) Clone() zapcore.Encoder {
+	return l.clone()
+}
+
+func (l *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{cfg: l.cfg, buf: _logfmtPool.Get()}
+
+	if final.cfg.TimeKey != "" && final.cfg.EncodeTime != nil {
+		final.appendPrimitive(final.cfg.TimeKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			final.cfg.EncodeTime(ent.Time, enc)
+		})
+	}
+	if final.cfg.LevelKey != "" && final.cfg.EncodeLevel != nil {
+		final.appendPrimitive(final.cfg.LevelKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			final.cfg.EncodeLevel(ent.Level, enc)
+		})
+	}
+	if ent.LoggerName != "" && final.cfg.NameKey != "" {
+		nameEncoder := final.cfg.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = zapcore.FullNameEncoder
+		}
+		final.appendPrimitive(final.cfg.NameKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			nameEncoder(ent.LoggerName, enc)
+		})
+	}
+	if ent.Caller.Defined {
+		if final.cfg.CallerKey != "" && final.cfg.EncodeCaller != nil {
+			final.appendPrimitive(final.cfg.CallerKey, func(enc zapcore.PrimitiveArrayEncoder) {
+				final.cfg.EncodeCaller(ent.Caller, enc)
+			})
+		}
+		if final.cfg.FunctionKey != "" {
+			final.AddString(final.cfg.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.cfg.MessageKey != "" {
+		final.AddString(final.cfg.MessageKey, ent.Message)
+	}
+	if l.buf.Len() > 0 {
+		final.addSeparator()
+		final.buf.Write(l.buf.Bytes())
+	}
+	final.prefix = l.prefix
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+	final.prefix = ""
+	if ent.Stack != "" && final.cfg.StacktraceKey != "" {
+		final.AddString(final.cfg.StacktraceKey, ent.Stack)
+	}
+	final.buf.AppendString(final.cfg.LineEnding)
+	return final.buf, nil
+}
+
+func (l *logfmtEncoder) addSeparator() {
+	if l.buf.Len() > 0 {
+		l.buf.AppendByte(' ')
+	}
+}
+
+// addKey writes the separator and the key in the current namespace,
+// replacing the characters which would break the pair.
+func (l *logfmtEncoder) addKey(key string) {
+	l.addSeparator()
+	writeLogfmtKey(l.buf, l.prefix+key)
+	l.buf.AppendByte('=')
+}
+
+// appendPrimitive writes the value appended by fn as a pair.
+func (l *logfmtEncoder) appendPrimitive(key string, fn func(zapcore.PrimitiveArrayEncoder)) {
+	arr := &logfmtArrayEncoder{cfg: l.cfg, buf: _logfmtPool.Get()}
+	fn(arr)
+	l.addKey(key)
+	writeLogfmtValue(l.buf, arr.buf.String())
+	arr.buf.Free()
+}
+
+func (l *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	enc := &logfmtArrayEncoder{cfg: l.cfg, buf: _logfmtPool.Get()}
+	defer enc.buf.Free()
+	enc.buf.AppendByte('[')
+	err := arr.MarshalLogArray(enc)
+	enc.buf.AppendByte(']')
+	l.addKey(key)
+	writeLogfmtValue(l.buf, enc.buf.String())
+	return err
+}
+
+func (l *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	prefix := l.prefix
+	l.prefix = prefix + key + "."
+	err := obj.MarshalLogObject(l)
+	l.prefix = prefix
+	return err
+}
+
+func (l *logfmtEncoder) AddBinary(key string, val []byte) {
+	l.AddString(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (l *logfmtEncoder) AddByteString(key string, val []byte) {
+	l.addKey(key)
+	writeLogfmtValue(l.buf, string(val))
+}
+
+func (l *logfmtEncoder) AddBool(key string, val bool) {
+	l.addKey(key)
+	l.buf.AppendBool(val)
+}
+
+func (l *logfmtEncoder) AddComplex128(key string, val complex128) {
+	l.addKey(key)
+	l.buf.AppendString(formatComplex(val, 64))
+}
+
+func (l *logfmtEncoder) AddComplex64(key string, val complex64) {
+	l.addKey(key)
+	l.buf.AppendString(formatComplex(complex128(val), 32))
+}
+
+func (l *logfmtEncoder) AddDuration(key string, val time.Duration) {
+	if l.cfg.EncodeDuration == nil {
+		l.AddInt64(key, int64(val))
+		return
+	}
+	l.appendPrimitive(key, func(enc zapcore.PrimitiveArrayEncoder) {
+		l.cfg.EncodeDuration(val, enc)
+	})
+}
+
+func (l *logfmtEncoder) AddFloat64(key string, val float64) {
+	l.addKey(key)
+	l.buf.AppendString(formatFloat(val, 64))
+}
+
+func (l *logfmtEncoder) AddFloat32(key string, val float32) {
+	l.addKey(key)
+	l.buf.AppendString(formatFloat(float64(val), 32))
+}
+
+func (l *logfmtEncoder) AddInt(key string, val int)     { l.AddInt64(key, int64(val)) }
+func (l *logfmtEncoder) AddInt32(key string, val int32) { l.AddInt64(key, int64(val)) }
+func (l *logfmtEncoder) AddInt16(key string, val int16) { l.AddInt64(key, int64(val)) }
+func (l *logfmtEncoder) AddInt8(key string, val int8)   { l.AddInt64(key, int64(val)) }
+
+func (l *logfmtEncoder) AddInt64(key string, val int64) {
+	l.addKey(key)
+	l.buf.AppendInt(val)
+}
+
+func (l *logfmtEncoder) AddString(key, val string) {
+	l.addKey(key)
+	writeLogfmtValue(l.buf, val)
+}
+
+func (l *logfmtEncoder) AddTime(key string, val time.Time) {
+	if l.cfg.EncodeTime == nil {
+		l.AddInt64(key, val.UnixNano())
+		return
+	}
+	l.appendPrimitive(key, func(enc zapcore.PrimitiveArrayEncoder) {
+		l.cfg.EncodeTime(val, enc)
+	})
+}
+
+func (l *logfmtEncoder) AddUint(key string, val uint)       { l.AddUint64(key, uint64(val)) }
+func (l *logfmtEncoder) AddUint32(key string, val uint32)   { l.AddUint64(key, uint64(val)) }
+func (l *logfmtEncoder) AddUint16(key string, val uint16)   { l.AddUint64(key, uint64(val)) }
+func (l *logfmtEncoder) AddUint8(key string, val uint8)     { l.AddUint64(key, uint64(val)) }
+func (l *logfmtEncoder) AddUintptr(key string, val uintptr) { l.AddUint64(key, uint64(val)) }
+
+func (l *logfmtEncoder) AddUint64(key string, val uint64) {
+	l.addKey(key)
+	l.buf.AppendUint(val)
+}
+
+func (l *logfmtEncoder) AddReflected(key string, obj interface{}) error {
+	val, err := encodeReflected(l.cfg, obj)
+	if err != nil {
+		return err
+	}
+	l.addKey(key)
+	writeLogfmtValue(l.buf, val)
+	return nil
+}
+
+func (l *logfmtEncoder) OpenNamespace(key string) {
+	l.prefix += key + "."
+}
+
+// logfmtArrayEncoder writes the elements of an array separated by comma, and
+// the fields of objects in the array as {key=value ...}.
+type logfmtArrayEncoder struct {
+	cfg *EncoderConfig
+	buf *buffer.Buffer
+	n   int
+}
+
+func (a *logfmtArrayEncoder) addSeparator() {
+	if a.n > 0 {
+		a.buf.AppendByte(',')
+	}
+	a.n++
+}
+
+func (a *logfmtArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	a.addSeparator()
+	enc := &logfmtArrayEncoder{cfg: a.cfg, buf: a.buf}
+	a.buf.AppendByte('[')
+	err := arr.MarshalLogArray(enc)
+	a.buf.AppendByte(']')
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	a.addSeparator()
+	enc := &logfmtEncoder{cfg: a.cfg, buf: _logfmtPool.Get()}
+	defer enc.buf.Free()
+	err := obj.MarshalLogObject(enc)
+	a.buf.AppendByte('{')
+	a.buf.Write(enc.buf.Bytes())
+	a.buf.AppendByte('}')
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendReflected(val interface{}) error {
+	s, err := encodeReflected(a.cfg, val)
+	if err != nil {
+		return err
+	}
+	a.addSeparator()
+	a.buf.AppendString(s)
+	return nil
+}
+
+func (a *logfmtArrayEncoder) AppendBool(val bool) {
+	a.addSeparator()
+	a.buf.AppendBool(val)
+}
+
+func (a *logfmtArrayEncoder) AppendByteString(val []byte) {
+	a.addSeparator()
+	a.buf.Write(val)
+}
+
+func (a *logfmtArrayEncoder) AppendComplex128(val complex128) {
+	a.addSeparator()
+	a.buf.AppendString(formatComplex(val, 64))
+}
+
+func (a *logfmtArrayEncoder) AppendComplex64(val complex64) {
+	a.addSeparator()
+	a.buf.AppendString(formatComplex(complex128(val), 32))
+}
+
+func (a *logfmtArrayEncoder) AppendFloat64(val float64) {
+	a.addSeparator()
+	a.buf.AppendString(formatFloat(val, 64))
+}
+
+func (a *logfmtArrayEncoder) AppendFloat32(val float32) {
+	a.addSeparator()
+	a.buf.AppendString(formatFloat(float64(val), 32))
+}
+
+func (a *logfmtArrayEncoder) AppendInt(val int)     { a.AppendInt64(int64(val)) }
+func (a *logfmtArrayEncoder) AppendInt32(val int32) { a.AppendInt64(int64(val)) }
+func (a *logfmtArrayEncoder) AppendInt16(val int16) { a.AppendInt64(int64(val)) }
+func (a *logfmtArrayEncoder) AppendInt8(val int8)   { a.AppendInt64(int64(val)) }
+
+func (a *logfmtArrayEncoder) AppendInt64(val int64) {
+	a.addSeparator()
+	a.buf.AppendInt(val)
+}
+
+func (a *logfmtArrayEncoder) AppendString(val string) {
+	a.addSeparator()
+	a.buf.AppendString(val)
+}
+
+func (a *logfmtArrayEncoder) AppendUint(val uint)       { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUint32(val uint32)   { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUint16(val uint16)   { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUint8(val uint8)     { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUintptr(val uintptr) { a.AppendUint64(uint64(val)) }
+
+func (a *logfmtArrayEncoder) AppendUint64(val uint64) {
+	a.addSeparator()
+	a.buf.AppendUint(val)
+}
+
+func (a *logfmtArrayEncoder) AppendDuration(val time.Duration) {
+	if a.cfg.EncodeDuration == nil {
+		a.AppendInt64(int64(val))
+		return
+	}
+	a.cfg.EncodeDuration(val, a)
+}
+
+func (a *logfmtArrayEncoder) AppendTime(val time.Time) {
+	if a.cfg.EncodeTime == nil {
+		a.AppendInt64(val.UnixNano())
+		return
+	}
+	a.cfg.EncodeTime(val, a)
+}
+
+// encodeReflected serializes obj with the configured reflected encoder,
+// json by default, without the trailing newline.
+func encodeReflected(cfg *EncoderConfig, obj interface{}) (string, error) {
+	buf := _logfmtPool.Get()
+	defer buf.Free()
+	newEnc := cfg.NewReflectedEncoder
+	if newEnc == nil {
+		newEnc = func(w io.Writer) zapcore.ReflectedEncoder {
+			return DefaultReflectedEncoder(w)
+		}
+	}
+	if err := newEnc(buf).Encode(obj); err != nil {
+		return "", err
+	}
+	b := buf.Bytes()
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		b = b[:n-1]
+	}
+	return string(b), nil
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
+
+func formatComplex(c complex128, bitSize int) string {
+	r, i := real(c), imag(c)
+	if i >= 0 || math.IsNaN(i) {
+		return fmt.Sprintf("%s+%si", formatFloat(r, bitSize), formatFloat(i, bitSize))
+	}
+	return fmt.Sprintf("%s%si", formatFloat(r, bitSize), formatFloat(i, bitSize))
+}
+
+// writeLogfmtKey writes the key, with the characters not allowed in a
+// logfmt key replaced by '_'.
+func writeLogfmtKey(buf *buffer.Buffer, key string) {
+	if key == "" {
+		buf.AppendByte('_')
+		return
+	}
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if r == utf8.RuneError || r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			buf.AppendByte('_')
+		} else {
+			buf.AppendString(key[i : i+size])
+		}
+		i += size
+	}
+}
+
+// logfmtNeedsQuote reports whether the value must be quoted.
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b <= ' ' || b == '=' || b == '"' || b == '\\' || b >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+// writeLogfmtValue writes the value, quoted and escaped if needed. Non
+// ASCII runes are kept as is when valid, and replaced by U+FFFD otherwise.
+func writeLogfmtValue(buf *buffer.Buffer, s string) {
+	if !logfmtNeedsQuote(s) {
+		buf.AppendString(s)
+		return
+	}
+	const hex = "0123456789abcdef"
+	buf.AppendByte('"')
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			switch b {
+			case '"', '\\':
+				buf.AppendByte('\\')
+				buf.AppendByte(b)
+			case '\n':
+				buf.AppendString(`\n`)
+			case '\r':
+				buf.AppendString(`\r`)
+			case '\t':
+				buf.AppendString(`\t`)
+			default:
+				if b < ' ' || b == 0x7f {
+					buf.AppendString(`\u00`)
+					buf.AppendByte(hex[b>>4])
+					buf.AppendByte(hex[b&0xf])
+				} else {
+					buf.AppendByte(b)
+				}
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.AppendString("\ufffd")
+		} else {
+			buf.AppendString(s[i : i+size])
+		}
+		i += size
+	}
+	buf.AppendByte('"')
+}
@@ -0,0 +1,48 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type logfmtUser struct {
+	name string
+	tags []string
+}
+
+func (u logfmtUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	return enc.AddArray("tags", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, t := range u.tags {
+			arr.AppendString(t)
+		}
+		return nil
+	}))
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wlog := NewWriterLogger(buf,
+		WithEncoding(LogfmtEncoding),
+		WithCaller(false),
+		WithStack(false),
+		WithTimeKey(""),
+		WithMessageKey("message"))
+
+	wlog.Info(context.TODO(), "hello \"world\"\n",
+		String("plain", "v"),
+		String("space", "a b"),
+		String("eq", "a=b"),
+		String("empty", ""),
+		ByteString("bad utf8", []byte{'x', 0xff}),
+		Field(zapcore.Field{Key: "user", Type: zapcore.ObjectMarshalerType, Interface: logfmtUser{name: "rumis", tags: []string{"a", "b"}}}),
+	)
+
+	want := `level=info message="hello \"world\"\n" plain=v space="a b" eq="a=b" empty="" bad_utf8="x` + "�" + `" user.name=rumis user.tags=[a,b]` + "\n"
+	if buf.String() != want {
+		t.Errorf("logfmt output\n got: %q\nwant: %q", buf.String(), want)
+	}
+}
@@ -38,3 +38,9 @@ type Logger interface {
 	// Applications should take care to call Sync before exiting.
 	Sync(ctx context.Context)
 }
+
+// Reopener is implemented by the loggers writing to files, which can close
+// and open again their files, e.g. after logrotate renamed them.
+type Reopener interface {
+	Reopen() error
+}
@@ -0,0 +1,337 @@
+package log4go
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LokiFormat is the payload of the requests sent by LokiLogger.
+type LokiFormat int
+
+const (
+	// LokiProtobuf payloads are snappy compressed protocol buffers.
+	LokiProtobuf LokiFormat = iota
+	// LokiJSON payloads are JSON documents, gzip compressed if Compress is
+	// set.
+	LokiJSON
+)
+
+const (
+	// lokiOverflowValue replaces the values of a label past
+	// LokiMaxLabelValues distinct values.
+	lokiOverflowValue = "_overflow"
+	// lokiMaxLabelValueLength is the longest label value sent, longer
+	// values are truncated.
+	lokiMaxLabelValueLength = 128
+)
+
+// lokiEntry is a line waiting to be pushed, with the stream it belongs to.
+type lokiEntry struct {
+	stream string
+	labels [][2]string
+	time   time.Time
+	line   string
+}
+
+// lokiCore encodes each entry without its label fields, and queues it in
+// the stream identified by the label values.
+type lokiCore struct {
+	zapcore.LevelEnabler
+	enc    Encoder
+	cfg    *EncoderConfig
+	fields []zapcore.Field
+	writer *lokiWriter
+}
+
+func (c *lokiCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+func (c *lokiCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *lokiCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	labels := make(map[string]string, len(c.writer.labels))
+	line := make([]zapcore.Field, 0, len(all))
+	for _, f := range all {
+		if !c.writer.labels[f.Key] {
+			line = append(line, f)
+			continue
+		}
+		m := zapcore.NewMapObjectEncoder()
+		f.AddTo(m)
+		labels[f.Key] = fmt.Sprint(flatValue(c.cfg, m.Fields[f.Key]))
+	}
+	if c.writer.labels["level"] {
+		labels["level"] = ent.Level.String()
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, line)
+	if err != nil {
+		return err
+	}
+	c.writer.add(ent.Time, labels, buf.String())
+	buf.Free()
+	if ent.Level > ErrorLevel {
+		// the process is about to exit
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync pushes the waiting entries from the background goroutine, waiting
+// for them at most DialTimeout as zap holds its lock meanwhile.
+func (c *lokiCore) Sync() error {
+	return c.writer.batcher.sync(c.writer.syncTimeout)
+}
+
+// lokiWriter batches the entries, and pushes them grouped by stream.
+type lokiWriter struct {
+	batcher   *batcher
+	poster    *httpPoster
+	format    LokiFormat
+	labels    map[string]bool
+	maxValues int
+
+	syncTimeout time.Duration
+
+	mu     sync.Mutex
+	values map[string]map[string]struct{}
+}
+
+// add queues the line in the stream of the labels, after bounding their
+// cardinality: values are truncated, and once a label has seen maxValues
+// distinct values, new ones are replaced by "_overflow".
+func (l *lokiWriter) add(t time.Time, labels map[string]string, line string) {
+	pairs := make([][2]string, 0, len(labels))
+	l.mu.Lock()
+	for k, v := range labels {
+		if len(v) > lokiMaxLabelValueLength {
+			v = v[:lokiMaxLabelValueLength]
+		}
+		seen := l.values[k]
+		if seen == nil {
+			seen = make(map[string]struct{})
+			l.values[k] = seen
+		}
+		if _, ok := seen[v]; !ok {
+			if l.maxValues > 0 && len(seen) >= l.maxValues {
+				v = lokiOverflowValue
+			} else {
+				seen[v] = struct{}{}
+			}
+		}
+		pairs = append(pairs, [2]string{lokiLabelName(k), v})
+	}
+	l.mu.Unlock()
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+
+	var stream strings.Builder
+	stream.WriteByte('{')
+	for i, p := range pairs {
+		if i > 0 {
+			stream.WriteString(", ")
+		}
+		stream.WriteString(p[0])
+		stream.WriteByte('=')
+		stream.WriteString(strconv.Quote(p[1]))
+	}
+	stream.WriteByte('}')
+
+	entry := &lokiEntry{stream: stream.String(), labels: pairs, time: t, line: line}
+	l.batcher.add(batchItem{payload: entry, size: len(entry.stream) + len(line)})
+}
+
+// send pushes the items grouped by stream, each stream in time order as
+// required by Loki.
+func (l *lokiWriter) send(items []batchItem) error {
+	streams := make(map[string][]*lokiEntry)
+	var names []string
+	for _, item := range items {
+		entry := item.payload.(*lokiEntry)
+		if _, ok := streams[entry.stream]; !ok {
+			names = append(names, entry.stream)
+		}
+		streams[entry.stream] = append(streams[entry.stream], entry)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entries := streams[name]
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+	}
+
+	if l.format == LokiJSON {
+		return l.poster.post(lokiJSON(names, streams), "application/json")
+	}
+	return l.poster.post(snappyEncode(lokiProtobuf(names, streams)), "application/x-protobuf")
+}
+
+// lokiJSON encodes the streams as a push request of the JSON API.
+func lokiJSON(names []string, streams map[string][]*lokiEntry) []byte {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	req := struct {
+		Streams []stream `json:"streams"`
+	}{Streams: make([]stream, 0, len(names))}
+	for _, name := range names {
+		entries := streams[name]
+		s := stream{Stream: make(map[string]string), Values: make([][2]string, 0, len(entries))}
+		for _, p := range entries[0].labels {
+			s.Stream[p[0]] = p[1]
+		}
+		for _, e := range entries {
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+		}
+		req.Streams = append(req.Streams, s)
+	}
+	b, _ := json.Marshal(req)
+	return b
+}
+
+// lokiProtobuf encodes the streams as a logproto.PushRequest:
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	message EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func lokiProtobuf(names []string, streams map[string][]*lokiEntry) []byte {
+	var req, stream, entry, ts []byte
+	for _, name := range names {
+		stream = appendProtoBytes(stream[:0], 1, []byte(name))
+		for _, e := range streams[name] {
+			ts = ts[:0]
+			if sec := e.time.Unix(); sec != 0 {
+				ts = appendProtoVarint(ts, 1, uint64(sec))
+			}
+			if nsec := e.time.Nanosecond(); nsec != 0 {
+				ts = appendProtoVarint(ts, 2, uint64(nsec))
+			}
+			entry = appendProtoBytes(entry[:0], 1, ts)
+			entry = appendProtoBytes(entry, 2, []byte(e.line))
+			stream = appendProtoBytes(stream, 2, entry)
+		}
+		req = appendProtoBytes(req, 1, stream)
+	}
+	return req
+}
+
+// appendProtoVarint appends a varint field.
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	var varint [binary.MaxVarintLen64]byte
+	b = append(b, byte(field<<3))
+	return append(b, varint[:binary.PutUvarint(varint[:], v)]...)
+}
+
+// appendProtoBytes appends a length delimited field.
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	var varint [binary.MaxVarintLen64]byte
+	b = append(b, byte(field<<3|2))
+	b = append(b, varint[:binary.PutUvarint(varint[:], uint64(len(v)))]...)
+	return append(b, v...)
+}
+
+// lokiLabelName returns the key with the characters not allowed in a label
+// name replaced by '_'.
+func lokiLabelName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// LokiLogger logger pushes entries to Grafana Loki
+type LokiLogger struct {
+	zapLogger
+	writer *lokiWriter
+}
+
+// NewLokiLogger create a new LokiLogger which pushes the entries to the
+// Loki push API at url, e.g. "http://localhost:3100/loki/api/v1/push".
+//
+// The fields whose keys are listed in LokiLabels, taken from the log site,
+// the extend fields or the context fields, are sent as the labels of the
+// entry stream rather than in the line; "level" stands for the entry level.
+// To keep the number of streams bounded, a label takes at most
+// LokiMaxLabelValues distinct values, later ones are sent as "_overflow".
+//
+// Entries are batched, retried and dropped as by HTTPLogger. Lines are json
+// encoded unless another encoding is selected.
+func NewLokiLogger(url string, oh ...OptionHandler) *LokiLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	opts.SkipLineEnding = true
+
+	labels := make(map[string]bool, len(opts.LokiLabels))
+	for _, k := range opts.LokiLabels {
+		labels[k] = true
+	}
+	w := &lokiWriter{
+		format:    opts.LokiFormat,
+		labels:    labels,
+		maxValues: opts.LokiMaxLabelValues,
+		values:    make(map[string]map[string]struct{}),
+
+		syncTimeout: opts.DialTimeout,
+	}
+	if w.syncTimeout <= 0 {
+		w.syncTimeout = 5 * time.Second
+	}
+	w.batcher = newBatcher(opts, w.send)
+	w.poster = newHTTPPoster(url, opts, &w.batcher.stats)
+	if opts.LokiFormat == LokiProtobuf {
+		// already snappy compressed
+		w.poster.compress = false
+	}
+
+	cfg := newEncoderConfig(opts)
+	level := zap.NewAtomicLevel()
+	level.SetLevel(opts.Level)
+	core := &lokiCore{
+		LevelEnabler: level,
+		enc:          newEncoder(cfg, opts, JSONEncoding),
+		cfg:          &cfg,
+		writer:       w,
+	}
+	return &LokiLogger{
+		zapLogger: newZapLoggerWithCore(opts, core),
+		writer:    w,
+	}
+}
+
+// Stats returns the number of entries sent, dropped and failed so far.
+func (l *LokiLogger) Stats() BatchStats {
+	return l.writer.batcher.Stats()
+}
+
+// Close pushes the waiting entries, and stops the background goroutine.
+func (l *LokiLogger) Close() error {
+	l.zap.Sync()
+	return l.writer.batcher.close()
+}
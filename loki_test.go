@@ -0,0 +1,181 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// snappyDecode decodes a snappy block, for checking snappyEncode.
+func snappyDecode(src []byte) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 {
+		return nil, errors.New("bad length")
+	}
+	src = src[k:]
+	dst := make([]byte, 0, n)
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			length = int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				size := length - 59
+				length = 0
+				for i := 0; i < size; i++ {
+					length |= int(src[i]) << (8 * i)
+				}
+				src = src[size:]
+			}
+			length++
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 1:
+			length = int(tag>>2&7) + 4
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case 2:
+			length = int(tag>>2) + 1
+			offset = int(src[1]) | int(src[2])<<8
+			src = src[3:]
+		default:
+			return nil, errors.New("unexpected 4 byte offset")
+		}
+		if offset == 0 || offset > len(dst) {
+			return nil, errors.New("bad offset")
+		}
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if uint64(len(dst)) != n {
+		return nil, errors.New("bad decoded length")
+	}
+	return dst, nil
+}
+
+func TestSnappyEncode(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		[]byte("abc"),
+		[]byte(strings.Repeat("log4go ", 1000)),
+		[]byte(strings.Repeat("a", 70000) + "tail" + strings.Repeat("0123456789", 300)),
+	}
+	for _, in := range inputs {
+		enc := snappyEncode(in)
+		out, err := snappyDecode(enc)
+		if err != nil || !bytes.Equal(out, in) {
+			t.Errorf("round trip of %d bytes failed: %v", len(in), err)
+		}
+	}
+	if enc := snappyEncode(inputs[2]); len(enc) > len(inputs[2])/10 {
+		t.Errorf("repetitive input compressed to %d bytes", len(enc))
+	}
+}
+
+func TestLokiLoggerJSON(t *testing.T) {
+	type push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	var mu sync.Mutex
+	var reqs []push
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p push
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		reqs = append(reqs, p)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	llog := NewLokiLogger(srv.URL, WithLokiFormat(LokiJSON), WithLokiLabels("level", "service", "user"),
+		WithLokiMaxLabelValues(2), WithExtendFields(String("service", "api")), WithBatchInterval(time.Hour))
+	ctx := context.WithValue(context.TODO(), ContextFieldsKey, []Field{String("user", "u1")})
+	llog.Info(ctx, "first", Int("n", 1))
+	llog.Warn(ctx, "second")
+	llog.Info(context.TODO(), "third", String("user", "u2"))
+	llog.Info(context.TODO(), "fourth", String("user", "u3"))
+	if err := llog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	streams := make(map[string][][2]string)
+	for _, s := range reqs[0].Streams {
+		streams[s.Stream["level"]+"/"+s.Stream["service"]+"/"+s.Stream["user"]] = s.Values
+	}
+	if len(streams) != 4 || len(streams["info/api/u1"]) != 1 || len(streams["warn/api/u1"]) != 1 ||
+		len(streams["info/api/u2"]) != 1 || len(streams["info/api/_overflow"]) != 1 {
+		t.Fatalf("unexpected streams %v", streams)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(streams["info/api/u1"][0][1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["msg"] != "first" || line["n"] != float64(1) || line["service"] != nil || line["user"] != nil {
+		t.Errorf("unexpected line %v", line)
+	}
+}
+
+func TestLokiLoggerSyncTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	llog := NewLokiLogger(srv.URL, WithDialTimeout(50*time.Millisecond), WithRetry(5, 100*time.Millisecond, 100*time.Millisecond))
+	llog.Info(context.TODO(), "retried while the endpoint is down")
+	start := time.Now()
+	if err := llog.zap.Sync(); err == nil {
+		t.Error("expected Sync to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("Sync blocked for %v", elapsed)
+	}
+	llog.Close()
+	if stats := llog.Stats(); stats.Failed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLokiLoggerProtobuf(t *testing.T) {
+	var body []byte
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		raw, _ := io.ReadAll(r.Body)
+		body, _ = snappyDecode(raw)
+	}))
+	defer srv.Close()
+
+	llog := NewLokiLogger(srv.URL, WithEncoding(LogfmtEncoding), WithCaller(false), WithStack(false))
+	llog.Error(context.TODO(), "failed", String("k", "v"))
+	llog.Close()
+
+	if contentType != "application/x-protobuf" {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+	if !bytes.Contains(body, []byte(`{level="error"}`)) || !bytes.Contains(body, []byte("msg=failed k=v")) {
+		t.Errorf("unexpected push request %q", body)
+	}
+}
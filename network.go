@@ -0,0 +1,311 @@
+package log4go
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialNetwork connects to the address on the network, which may also be
+// "tls" for TLS over TCP.
+func dialNetwork(network, address string, timeout time.Duration, tlsConfig *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	}
+	return dialer.Dial(network, address)
+}
+
+// isStreamNetwork reports whether the network delivers a stream of bytes,
+// so that messages have to be framed, rather than datagrams.
+func isStreamNetwork(network string) bool {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram", "ip", "ip4", "ip6":
+		return false
+	}
+	return true
+}
+
+// NetworkStats counts the events of a NetworkLogger connection.
+type NetworkStats struct {
+	// Reconnects is the number of connections established after the first
+	// one.
+	Reconnects uint64
+	// Spooled is the number of entries written to the spool while
+	// disconnected.
+	Spooled uint64
+	// Dropped is the number of entries discarded while disconnected,
+	// because no spool is configured or the spool is full.
+	Dropped uint64
+}
+
+// netWriter writes each entry to the connection. While the connection is
+// down, entries are appended to the spool file, to be sent first once it is
+// established again. Connections are attempted on writes, with exponential
+// backoff between failed attempts, and each dial or write on the connection
+// waits at most DialTimeout, so that a stalled peer doesn't block the
+// logger: the entry is spooled instead.
+type netWriter struct {
+	network    string
+	address    string
+	timeout    time.Duration
+	tlsConfig  *tls.Config
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	spoolPath  string
+	spoolMax   int64
+
+	mu          sync.Mutex
+	conn        net.Conn
+	connected   bool
+	failures    int
+	nextDial    time.Time
+	spool       *os.File
+	spoolOffset int64
+	spoolSize   int64
+	stats       NetworkStats
+}
+
+func (n *netWriter) Write(p []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.connect() && n.replay() == nil && n.send(p) == nil {
+		return len(p), nil
+	}
+	return len(p), n.store(p)
+}
+
+// send writes b to the connection within the timeout, and drops the
+// connection on failure. A peer which doesn't read in time is dialed again
+// after the backoff delay, as after a failed dial.
+func (n *netWriter) send(b []byte) error {
+	if n.timeout > 0 {
+		n.conn.SetWriteDeadline(time.Now().Add(n.timeout))
+	}
+	_, err := n.conn.Write(b)
+	if err != nil {
+		n.disconnect()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			n.nextDial = time.Now().Add(backoffDelay(n.failures, n.minBackoff, n.maxBackoff))
+			n.failures++
+		}
+	}
+	return err
+}
+
+// connect establishes the connection unless it is up or the backoff delay
+// isn't over, and reports whether it is up.
+func (n *netWriter) connect() bool {
+	if n.conn != nil {
+		return true
+	}
+	if time.Now().Before(n.nextDial) {
+		return false
+	}
+	conn, err := dialNetwork(n.network, n.address, n.timeout, n.tlsConfig)
+	if err != nil {
+		n.nextDial = time.Now().Add(backoffDelay(n.failures, n.minBackoff, n.maxBackoff))
+		n.failures++
+		return false
+	}
+	if n.connected {
+		n.stats.Reconnects++
+	}
+	n.conn = conn
+	n.connected = true
+	n.failures = 0
+	return true
+}
+
+func (n *netWriter) disconnect() {
+	n.conn.Close()
+	n.conn = nil
+}
+
+// store appends the entry to the spool, or drops it.
+func (n *netWriter) store(p []byte) error {
+	if n.spoolPath == "" {
+		n.stats.Dropped++
+		return nil
+	}
+	if err := n.openSpool(); err != nil {
+		n.stats.Dropped++
+		return err
+	}
+	size := int64(len(p))
+	if len(p) == 0 || p[len(p)-1] != '\n' {
+		size++
+	}
+	if n.spoolMax > 0 && n.spoolSize+size > n.spoolMax {
+		n.stats.Dropped++
+		return nil
+	}
+	buf := make([]byte, 0, size)
+	buf = append(append(buf, p...), '\n')
+	written, err := n.spool.Write(buf[:size])
+	n.spoolSize += int64(written)
+	if err != nil {
+		n.stats.Dropped++
+		return err
+	}
+	n.stats.Spooled++
+	return nil
+}
+
+// openSpool opens the spool file, which may hold the entries left by a
+// previous process.
+func (n *netWriter) openSpool() error {
+	if n.spool != nil {
+		return nil
+	}
+	f, err := os.OpenFile(n.spoolPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	n.spool = f
+	n.spoolOffset = 0
+	n.spoolSize = info.Size()
+	return nil
+}
+
+// replay sends the spooled entries, one per datagram or in chunks of
+// entries over streams. On failure, the entries not sent yet are kept for
+// the next connection.
+func (n *netWriter) replay() error {
+	if n.spool == nil || n.spoolOffset == n.spoolSize {
+		return nil
+	}
+	stream := isStreamNetwork(n.network)
+	r := bufio.NewReader(io.NewSectionReader(n.spool, n.spoolOffset, n.spoolSize-n.spoolOffset))
+	var chunk []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			chunk = append(chunk, line...)
+		}
+		if len(chunk) > 0 && (!stream || len(chunk) >= 64<<10 || err != nil) {
+			if werr := n.send(chunk); werr != nil {
+				return werr
+			}
+			n.spoolOffset += int64(len(chunk))
+			chunk = chunk[:0]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	n.spoolOffset, n.spoolSize = 0, 0
+	return n.spool.Truncate(0)
+}
+
+// Sync sends the spooled entries if the connection can be established.
+func (n *netWriter) Sync() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.connect() {
+		return nil
+	}
+	return n.replay()
+}
+
+// Close closes the connection and the spool. Entries left in the spool are
+// sent by the next logger using it.
+func (n *netWriter) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var err error
+	if n.conn != nil {
+		err = n.conn.Close()
+		n.conn = nil
+	}
+	if n.spool != nil {
+		if serr := n.spool.Close(); err == nil {
+			err = serr
+		}
+		n.spool = nil
+	}
+	return err
+}
+
+// NetworkLogger logger writes newline delimited entries to a socket
+type NetworkLogger struct {
+	zapLogger
+	writer *netWriter
+}
+
+// NewNetworkLogger create a new NetworkLogger which writes json encoded
+// entries, one per line, to the address on the network: "tcp", "udp",
+// "unix", "unixgram", or "tls" for TLS over TCP configured by TLSConfig.
+//
+// The connection is established on the first entry. When it fails, it is
+// established again on later entries, waiting between attempts from
+// RetryBackoff up to RetryMaxBackoff. Meanwhile, entries are appended to a
+// spool file in SpoolDir, up to SpoolMaxBytes, and sent first once the
+// connection is up; without a SpoolDir, they are dropped. A peer which
+// doesn't read an entry within DialTimeout is handled as a failed
+// connection. Over UDP, entries lost by the network can't be detected.
+func NewNetworkLogger(network, address string, oh ...OptionHandler) *NetworkLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	opts.SkipLineEnding = false
+
+	w := &netWriter{
+		network:    network,
+		address:    address,
+		timeout:    opts.DialTimeout,
+		tlsConfig:  opts.TLSConfig,
+		minBackoff: opts.RetryBackoff,
+		maxBackoff: opts.RetryMaxBackoff,
+		spoolMax:   opts.SpoolMaxBytes,
+	}
+	if opts.SpoolDir != "" {
+		name := strings.Map(func(r rune) rune {
+			if r == '/' || r == ':' || r == '\\' {
+				return '_'
+			}
+			return r
+		}, network+"-"+address)
+		w.spoolPath = filepath.Join(opts.SpoolDir, "log4go-"+name+".spool")
+		if info, err := os.Stat(w.spoolPath); err == nil && info.Size() > 0 {
+			// left by a previous process
+			w.openSpool()
+		}
+	}
+	return &NetworkLogger{
+		zapLogger: newZapLogger(opts, w, JSONEncoding),
+		writer:    w,
+	}
+}
+
+// Stats returns the number of reconnections, spooled and dropped entries so
+// far.
+func (n *NetworkLogger) Stats() NetworkStats {
+	n.writer.mu.Lock()
+	defer n.writer.mu.Unlock()
+	return n.writer.stats
+}
+
+// Close flushes any buffered log entries, and closes the connection.
+func (n *NetworkLogger) Close() error {
+	n.zap.Sync()
+	return n.writer.Close()
+}
@@ -0,0 +1,112 @@
+package log4go
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetworkLoggerSpool(t *testing.T) {
+	// reserve a port nobody listens on yet
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx := context.TODO()
+	nlog := NewNetworkLogger("tcp", addr, WithSpool(t.TempDir(), 1<<20),
+		WithRetry(0, time.Millisecond, 5*time.Millisecond), WithDialTimeout(time.Second))
+	defer nlog.Close()
+	nlog.Info(ctx, "spooled", Int("i", 0))
+	nlog.Info(ctx, "spooled", Int("i", 1))
+	if stats := nlog.Stats(); stats.Spooled != 2 || stats.Dropped != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("port taken meanwhile: %v", err)
+	}
+	defer ln.Close()
+	time.Sleep(20 * time.Millisecond)
+	nlog.Info(ctx, "live", Int("i", 2))
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for i := 0; i < 3; i++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("read entry %d: %v", i, err)
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("unmarshal %q: %v", line, err)
+		}
+		if entry["i"] != float64(i) {
+			t.Errorf("entry %d out of order: %s", i, line)
+		}
+	}
+}
+
+func TestNetworkLoggerDrop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	nlog := NewNetworkLogger("tcp", addr, WithRetry(0, time.Hour, time.Hour))
+	defer nlog.Close()
+	nlog.Info(context.TODO(), "dropped")
+	nlog.Info(context.TODO(), "dropped")
+	if stats := nlog.Stats(); stats.Dropped != 2 || stats.Spooled != 0 || stats.Reconnects != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestNetworkLoggerWriteTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 1)
+	go func() {
+		// accept, but never read
+		if conn, err := ln.Accept(); err == nil {
+			conns <- conn
+		}
+	}()
+
+	nlog := NewNetworkLogger("tcp", ln.Addr().String(), WithSpool(t.TempDir(), 1<<30),
+		WithRetry(0, time.Hour, time.Hour), WithDialTimeout(100*time.Millisecond))
+	defer nlog.Close()
+	big := strings.Repeat("x", 1<<20)
+	for i := 0; i < 64 && nlog.Stats().Spooled == 0; i++ {
+		start := time.Now()
+		nlog.Info(context.TODO(), big)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("write blocked for %v", elapsed)
+		}
+	}
+	if stats := nlog.Stats(); stats.Spooled != 1 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	select {
+	case conn := <-conns:
+		conn.Close()
+	default:
+	}
+}
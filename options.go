@@ -1,8 +1,13 @@
 package log4go
 
 import (
+	"crypto/ed25519"
+	"crypto/tls"
 	"io"
+	"net/http"
+	"os"
 	"strings"
+	"time"
 )
 
 type Options struct {
@@ -35,6 +40,40 @@ type Options struct {
 	// to tab.
 	ConsoleSeparator string
 
+	// Encoding selects the encoder by its registered name: one of the
+	// *Encoding constants, such as "json", "console" or "logfmt", or a name
+	// registered with RegisterEncoder. Empty uses the logger's default:
+	// console for ConsoleLogger and json for the others.
+	Encoding string
+
+	// Pattern is the log4j style layout of the pattern encoding, see
+	// NewPatternEncoder. Empty uses DefaultPattern.
+	Pattern string
+
+	// Name names the logger. The name is written under NameKey, and can be
+	// written in pattern layouts by %c.
+	Name string
+
+	// Output replaces os.Stdout as the destination of ConsoleLogger. If it
+	// implements WriteSyncer, its Sync method is called when the logger is
+	// synced.
+	Output io.Writer
+
+	// Color colorizes the level of console encoded output. It is ignored
+	// unless the output is a terminal and the NO_COLOR environment variable
+	// is unset, or ForceColor is set.
+	Color bool
+
+	// ColorFields colorizes the keys and values of the structured context
+	// when Color is in effect.
+	ColorFields bool
+
+	// ColorMessage highlights the message when Color is in effect.
+	ColorMessage bool
+
+	// ForceColor colorizes the output whatever it is, implying Color.
+	ForceColor bool
+
 	// Level level
 	Level Level
 
@@ -47,12 +86,12 @@ type Options struct {
 	WithCaller bool
 
 	// Filename is the file to write logs to.  Backup log files will be retained
-	// in the same directory.  It uses <processname>-lumberjack.log in
+	// in the same directory.  It uses <processname>-log4go.log in
 	// os.TempDir() if empty.
 	Filename string
 
 	// MaxSize is the maximum size in megabytes of the log file before it gets
-	// rotated. It defaults to 100 megabytes.
+	// rotated. It defaults to 100 megabytes, 0 means no size limit.
 	MaxSize int
 
 	// MaxAge is the maximum number of days to retain old log files based on the
@@ -67,15 +106,244 @@ type Options struct {
 	// deleted.)
 	MaxBackups int
 
+	// MaxTotalSize is the maximum size in megabytes of the old log files,
+	// the oldest ones are removed first. The default is not to remove old
+	// log files based on size.
+	MaxTotalSize int
+
+	// DiskBudget bounds the total size of the old log files of all the
+	// file loggers sharing it, e.g. the loggers writing to a directory.
+	DiskBudget *DiskBudget
+
+	// MinFreeDisk is the free space in bytes of the file system holding the
+	// log file below which FileLogger drops the entries under LowDiskLevel,
+	// Info by default. The free space isn't checked if zero as by default,
+	// nor on platforms other than Linux and macOS.
+	MinFreeDisk  int64
+	LowDiskLevel Level
+
+	// RotationPolicy rotates the log file on time, in addition to MaxSize.
+	// The default is to rotate on size only.
+	RotationPolicy RotationPolicy
+
+	// FilenamePattern names the log file of each rotation period, expanding
+	// strftime like conversions such as %Y, %m, %d and %H with the start of
+	// the period. It overrides Filename if set.
+	FilenamePattern string
+
 	// LocalTime determines if the time used for formatting the timestamps in
 	// backup files is the computer's local time.  The default is to use UTC
-	// time.
+	// time. It also applies to RotationPolicy and FilenamePattern.
 	LocalTime bool
 
 	// Compress determines if the rotated log files should be compressed
-	// using gzip. The default is not to perform compression.
+	// using gzip. The default is not to perform compression. GELFLogger
+	// compresses the messages sent over UDP unless it is unset, HTTPLogger
+	// compresses the request bodies if it is set.
 	Compress bool
 
+	// Compressor compresses the rotated log files, gzip if unset and
+	// Compress is set.
+	Compressor Compressor
+
+	// FileMode is the permission of the log files created. It defaults to
+	// 0600.
+	FileMode os.FileMode
+
+	// FileUID and FileGID own the log files created, unless negative as by
+	// default.
+	FileUID int
+	FileGID int
+
+	// CurrentLink is the path of a symbolic link to the log file written,
+	// updated on rotation.
+	CurrentLink string
+
+	// RotateCallbacks are called after each rotation of the log file, with
+	// the writer locked, so they mustn't log to the same file.
+	RotateCallbacks []func(RotateEvent)
+
+	// FsyncEvery commits the log file to stable storage every FsyncEvery
+	// entries, and FsyncInterval at most FsyncInterval after an entry was
+	// written. If FsyncOnLevel is set, the entries at or above FsyncLevel
+	// are committed before the logging call returns. The default is to
+	// commit the file on Sync only, and DisableFsync never commits it, e.g.
+	// when the durability is left to the operating system.
+	FsyncEvery    int
+	FsyncInterval time.Duration
+	FsyncOnLevel  bool
+	FsyncLevel    Level
+	DisableFsync  bool
+
+	// Encryption encrypts the log files with AES-GCM, with the keys
+	// supplied by the KeyProvider. They are read by DecryptReader or the
+	// log4go-decrypt command.
+	Encryption KeyProvider
+
+	// Audit chains the entries of the file loggers by a sequence number
+	// and a hash, HMAC-SHA256 with AuditKey if set, checked by
+	// AuditVerifier. A checkpoint entry, signed with AuditSigningKey if set,
+	// is written every AuditCheckpointEvery entries. The entries are
+	// encoded in JSON whatever the Encoding.
+	Audit                bool
+	AuditKey             []byte
+	AuditSigningKey      ed25519.PrivateKey
+	AuditCheckpointEvery int
+
+	// RedactRules redact the message and the fields of the entries, those
+	// of the log site, ExtFields and the context alike, before they reach
+	// the sink. RedactSalt salts the hashes of RedactHash.
+	RedactRules []RedactRule
+	RedactSalt  []byte
+
+	// Sanitize strips the ANSI escape sequences, and escapes the control
+	// characters and invalid UTF-8 of the message and string fields, so
+	// that user input can't break the line structure of the output or
+	// forge entries.
+	Sanitize bool
+
+	// MaxMessageLength and MaxFieldLength are the maximum lengths in bytes
+	// of the message and of the field values, MaxFields the maximum number
+	// of fields, and MaxEntrySize the maximum size in bytes of an entry
+	// encoded in JSON, stack trace aside. The values beyond are truncated
+	// with a marker such as "…[truncated 1.2MB]", the fields beyond are
+	// dropped and counted in a "truncated_fields" field. Zero means no limit.
+	MaxMessageLength int
+	MaxFieldLength   int
+	MaxFields        int
+	MaxEntrySize     int
+
+	// SampleInitial entries of each message and level are logged per
+	// SampleInterval, then every SampleThereafter-th, none if zero. The
+	// entries aren't sampled unless both SampleInitial and SampleInterval
+	// are set, nor those of SampleExemptLevels, e.g. errors. SampleHook is
+	// called with the decision on each entry sampled.
+	SampleInterval     time.Duration
+	SampleInitial      int
+	SampleThereafter   int
+	SampleHook         func(Entry, SamplingDecision)
+	SampleExemptLevels []Level
+
+	// LevelFiles route the entries of their level to their own file, rather
+	// than the main file of FileLogger.
+	LevelFiles []LevelFile
+
+	// DuplicateToMain also writes the entries at or above DuplicateLevel
+	// routed to level files to the main file.
+	DuplicateToMain bool
+	DuplicateLevel  Level
+
+	// Clock and FileSystem are used by the file loggers, in place of the
+	// system ones, e.g. in tests.
+	Clock      Clock
+	FileSystem FileSystem
+
+	// Hostname is the host name written by the network encodings. It
+	// defaults to the name reported by the kernel.
+	Hostname string
+
+	// DialTimeout is the maximum amount of time network loggers wait for a
+	// connection to complete. It defaults to 5 seconds.
+	DialTimeout time.Duration
+
+	// TLSConfig configures the network loggers connecting over "tls".
+	TLSConfig *tls.Config
+
+	// SyslogFormat is the layout of the messages sent by SyslogLogger.
+	SyslogFormat SyslogFormat
+
+	// SyslogFacility is the facility of the messages sent by SyslogLogger.
+	// It defaults to FacilityUser.
+	SyslogFacility SyslogFacility
+
+	// SyslogSDID is the SD-ID of the structured data element carrying the
+	// fields in RFC 5424 messages. It defaults to DefaultSyslogSDID.
+	SyslogSDID string
+
+	// AppName identifies the application in syslog messages and journal
+	// entries. It defaults to the name of the executable.
+	AppName string
+
+	// ProcID identifies the process in syslog messages. It defaults to the
+	// process id.
+	ProcID string
+
+	// GELFChunkSize is the maximum size of the UDP datagrams sent by
+	// GELFLogger, larger messages are chunked. It defaults to 1420 bytes.
+	GELFChunkSize int
+
+	// GELFDelimiter ends each message sent by GELFLogger over TCP. It
+	// defaults to the null byte, some servers also accept '\n'.
+	GELFDelimiter byte
+
+	// BatchSize is the maximum number of entries sent in one request by the
+	// batching loggers. It defaults to 100.
+	BatchSize int
+
+	// BatchInterval is the maximum amount of time an entry waits for its
+	// batch to fill up before being sent. It defaults to 1 second.
+	BatchInterval time.Duration
+
+	// BatchMaxBytes bounds the size of the entries waiting to be sent,
+	// entries logged over the bound are dropped and counted. It defaults to
+	// 8 MiB, 0 means unbounded.
+	BatchMaxBytes int
+
+	// BatchFormat is the layout of the request bodies sent by HTTPLogger.
+	BatchFormat BatchFormat
+
+	// RetryMax is the number of times a failed request is retried before
+	// its entries are dropped. It defaults to 5.
+	RetryMax int
+
+	// RetryBackoff is the delay before the first retry, doubled after each
+	// attempt up to RetryMaxBackoff. They default to 500 milliseconds and
+	// 30 seconds.
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+
+	// HTTPHeaders are set on each request, e.g. to carry the credentials.
+	HTTPHeaders map[string]string
+
+	// HTTPClient sends the requests, it defaults to a client with a 10
+	// seconds timeout.
+	HTTPClient *http.Client
+
+	// SpoolDir is the directory where NetworkLogger spools the entries
+	// while disconnected. Entries are dropped if it is empty.
+	SpoolDir string
+
+	// SpoolMaxBytes bounds the size of the spool file, entries over the
+	// bound are dropped. It defaults to 64 MiB, 0 means unbounded.
+	SpoolMaxBytes int64
+
+	// FluentTagPrefix prefixes the logger name in the tags of the entries
+	// sent by FluentLogger.
+	FluentTagPrefix string
+
+	// FluentMode is the Forward protocol mode used by FluentLogger.
+	FluentMode FluentMode
+
+	// FluentAck makes FluentLogger require the server to acknowledge each
+	// message, which is sent again otherwise.
+	FluentAck bool
+
+	// FluentAckTimeout is the maximum amount of time FluentLogger waits for
+	// an acknowledgement. It defaults to 10 seconds.
+	FluentAckTimeout time.Duration
+
+	// LokiLabels are the keys of the fields sent by LokiLogger as stream
+	// labels, "level" stands for the entry level. It defaults to level.
+	LokiLabels []string
+
+	// LokiFormat is the payload of the requests sent by LokiLogger.
+	LokiFormat LokiFormat
+
+	// LokiMaxLabelValues is the number of distinct values a label may take,
+	// later values are sent as "_overflow". It defaults to 100, 0 means
+	// unbounded.
+	LokiMaxLabelValues int
+
 	// ExtFields configures the Logger to annotate each message with the extend fields.
 	ExtFields []Field
 }
@@ -177,6 +445,56 @@ func WithConsoleSeparator(sep string) OptionHandler {
 	}
 }
 
+func WithEncoding(name string) OptionHandler {
+	return func(opt *Options) {
+		opt.Encoding = name
+	}
+}
+
+// WithPattern selects the pattern encoding with the given layout.
+func WithPattern(pattern string) OptionHandler {
+	return func(opt *Options) {
+		opt.Encoding = PatternEncoding
+		opt.Pattern = pattern
+	}
+}
+
+func WithName(name string) OptionHandler {
+	return func(opt *Options) {
+		opt.Name = name
+	}
+}
+
+func WithOutput(w io.Writer) OptionHandler {
+	return func(opt *Options) {
+		opt.Output = w
+	}
+}
+
+func WithColor(color bool) OptionHandler {
+	return func(opt *Options) {
+		opt.Color = color
+	}
+}
+
+func WithColorFields(color bool) OptionHandler {
+	return func(opt *Options) {
+		opt.ColorFields = color
+	}
+}
+
+func WithColorMessage(color bool) OptionHandler {
+	return func(opt *Options) {
+		opt.ColorMessage = color
+	}
+}
+
+func WithForceColor(force bool) OptionHandler {
+	return func(opt *Options) {
+		opt.ForceColor = force
+	}
+}
+
 func WithFileName(filepath string) OptionHandler {
 	return func(opt *Options) {
 		opt.Filename = filepath
@@ -201,6 +519,226 @@ func WithMaxBackups(maxb int) OptionHandler {
 	}
 }
 
+func WithRotation(policy RotationPolicy) OptionHandler {
+	return func(opt *Options) {
+		opt.RotationPolicy = policy
+	}
+}
+
+func WithFilenamePattern(pattern string) OptionHandler {
+	return func(opt *Options) {
+		opt.FilenamePattern = pattern
+	}
+}
+
+func WithMaxTotalSize(size int) OptionHandler {
+	return func(opt *Options) {
+		opt.MaxTotalSize = size
+	}
+}
+
+func WithDiskBudget(b *DiskBudget) OptionHandler {
+	return func(opt *Options) {
+		opt.DiskBudget = b
+	}
+}
+
+// WithLowDiskGuard drops the entries below lvl while the free disk space is
+// under minFree bytes.
+func WithLowDiskGuard(minFree int64, lvl Level) OptionHandler {
+	return func(opt *Options) {
+		opt.MinFreeDisk = minFree
+		opt.LowDiskLevel = lvl
+	}
+}
+
+func WithCompressor(c Compressor) OptionHandler {
+	return func(opt *Options) {
+		opt.Compressor = c
+	}
+}
+
+func WithFileMode(mode os.FileMode) OptionHandler {
+	return func(opt *Options) {
+		opt.FileMode = mode
+	}
+}
+
+func WithFileOwner(uid, gid int) OptionHandler {
+	return func(opt *Options) {
+		opt.FileUID = uid
+		opt.FileGID = gid
+	}
+}
+
+func WithCurrentLink(path string) OptionHandler {
+	return func(opt *Options) {
+		opt.CurrentLink = path
+	}
+}
+
+// WithRotateCallback adds a function called after each rotation.
+func WithRotateCallback(fn func(RotateEvent)) OptionHandler {
+	return func(opt *Options) {
+		opt.RotateCallbacks = append(opt.RotateCallbacks[:len(opt.RotateCallbacks):len(opt.RotateCallbacks)], fn)
+	}
+}
+
+// WithFsyncEvery commits the log file every n entries, and at most interval
+// after an entry was written, either disabled if zero.
+func WithFsyncEvery(n int, interval time.Duration) OptionHandler {
+	return func(opt *Options) {
+		opt.FsyncEvery = n
+		opt.FsyncInterval = interval
+	}
+}
+
+// WithFsyncLevel commits the log file after each entry at or above the
+// level.
+func WithFsyncLevel(lvl Level) OptionHandler {
+	return func(opt *Options) {
+		opt.FsyncOnLevel = true
+		opt.FsyncLevel = lvl
+	}
+}
+
+func WithDisableFsync(disable bool) OptionHandler {
+	return func(opt *Options) {
+		opt.DisableFsync = disable
+	}
+}
+
+func WithEncryption(keys KeyProvider) OptionHandler {
+	return func(opt *Options) {
+		opt.Encryption = keys
+	}
+}
+
+// WithAudit chains the entries of the file loggers, with key as HMAC key if
+// not empty.
+func WithAudit(key []byte) OptionHandler {
+	return func(opt *Options) {
+		opt.Audit = true
+		opt.AuditKey = key
+	}
+}
+
+// WithAuditCheckpoint writes a checkpoint entry every n entries of the
+// audit chain, signed with key if not nil.
+func WithAuditCheckpoint(n int, key ed25519.PrivateKey) OptionHandler {
+	return func(opt *Options) {
+		opt.AuditCheckpointEvery = n
+		opt.AuditSigningKey = key
+	}
+}
+
+// WithRedaction adds redaction rules, applied in order.
+func WithRedaction(rules ...RedactRule) OptionHandler {
+	return func(opt *Options) {
+		opt.RedactRules = append(opt.RedactRules[:len(opt.RedactRules):len(opt.RedactRules)], rules...)
+	}
+}
+
+func WithRedactSalt(salt []byte) OptionHandler {
+	return func(opt *Options) {
+		opt.RedactSalt = salt
+	}
+}
+
+func WithSanitize(sanitize bool) OptionHandler {
+	return func(opt *Options) {
+		opt.Sanitize = sanitize
+	}
+}
+
+func WithMaxMessageLength(n int) OptionHandler {
+	return func(opt *Options) {
+		opt.MaxMessageLength = n
+	}
+}
+
+func WithMaxFieldLength(n int) OptionHandler {
+	return func(opt *Options) {
+		opt.MaxFieldLength = n
+	}
+}
+
+func WithMaxFields(n int) OptionHandler {
+	return func(opt *Options) {
+		opt.MaxFields = n
+	}
+}
+
+func WithMaxEntrySize(n int) OptionHandler {
+	return func(opt *Options) {
+		opt.MaxEntrySize = n
+	}
+}
+
+// WithSampling logs the first entries of each message and level per
+// interval, then every thereafter-th.
+func WithSampling(interval time.Duration, first, thereafter int) OptionHandler {
+	return func(opt *Options) {
+		opt.SampleInterval = interval
+		opt.SampleInitial = first
+		opt.SampleThereafter = thereafter
+	}
+}
+
+func WithSampleHook(hook func(Entry, SamplingDecision)) OptionHandler {
+	return func(opt *Options) {
+		opt.SampleHook = hook
+	}
+}
+
+// WithSampleExempt never samples the entries of the levels.
+func WithSampleExempt(levels ...Level) OptionHandler {
+	return func(opt *Options) {
+		opt.SampleExemptLevels = append(opt.SampleExemptLevels[:len(opt.SampleExemptLevels):len(opt.SampleExemptLevels)], levels...)
+	}
+}
+
+// WithLevelFile routes the entries of the level to their own file,
+// configured by the logger options overridden by oh. Only the file and
+// encoder options of oh apply, the caller, stack and name options are those
+// of the logger.
+func WithLevelFile(lvl Level, oh ...OptionHandler) OptionHandler {
+	return func(opt *Options) {
+		opt.LevelFiles = append(opt.LevelFiles[:len(opt.LevelFiles):len(opt.LevelFiles)], LevelFile{Level: lvl, Options: oh})
+	}
+}
+
+// WithSplitByLevel routes the entries of each level to their own file,
+// configured by the logger options.
+func WithSplitByLevel(levels ...Level) OptionHandler {
+	return func(opt *Options) {
+		for _, lvl := range levels {
+			WithLevelFile(lvl)(opt)
+		}
+	}
+}
+
+// WithDuplicateToMain also writes the entries at or above the level routed
+// to level files to the main file.
+func WithDuplicateToMain(lvl Level) OptionHandler {
+	return func(opt *Options) {
+		opt.DuplicateToMain = true
+		opt.DuplicateLevel = lvl
+	}
+}
+
+func WithClock(c Clock) OptionHandler {
+	return func(opt *Options) {
+		opt.Clock = c
+	}
+}
+
+func WithFileSystem(fs FileSystem) OptionHandler {
+	return func(opt *Options) {
+		opt.FileSystem = fs
+	}
+}
+
 func WithLocalTime(lc bool) OptionHandler {
 	return func(opt *Options) {
 		opt.LocalTime = lc
@@ -213,6 +751,160 @@ func WithCompress(compress bool) OptionHandler {
 	}
 }
 
+func WithHostname(host string) OptionHandler {
+	return func(opt *Options) {
+		opt.Hostname = host
+	}
+}
+
+func WithDialTimeout(timeout time.Duration) OptionHandler {
+	return func(opt *Options) {
+		opt.DialTimeout = timeout
+	}
+}
+
+func WithTLSConfig(cfg *tls.Config) OptionHandler {
+	return func(opt *Options) {
+		opt.TLSConfig = cfg
+	}
+}
+
+func WithSyslogFormat(format SyslogFormat) OptionHandler {
+	return func(opt *Options) {
+		opt.SyslogFormat = format
+	}
+}
+
+func WithSyslogFacility(facility SyslogFacility) OptionHandler {
+	return func(opt *Options) {
+		opt.SyslogFacility = facility
+	}
+}
+
+func WithSyslogSDID(id string) OptionHandler {
+	return func(opt *Options) {
+		opt.SyslogSDID = id
+	}
+}
+
+func WithAppName(name string) OptionHandler {
+	return func(opt *Options) {
+		opt.AppName = name
+	}
+}
+
+func WithProcID(id string) OptionHandler {
+	return func(opt *Options) {
+		opt.ProcID = id
+	}
+}
+
+func WithGELFChunkSize(size int) OptionHandler {
+	return func(opt *Options) {
+		opt.GELFChunkSize = size
+	}
+}
+
+func WithGELFDelimiter(delim byte) OptionHandler {
+	return func(opt *Options) {
+		opt.GELFDelimiter = delim
+	}
+}
+
+func WithBatchSize(size int) OptionHandler {
+	return func(opt *Options) {
+		opt.BatchSize = size
+	}
+}
+
+func WithBatchInterval(interval time.Duration) OptionHandler {
+	return func(opt *Options) {
+		opt.BatchInterval = interval
+	}
+}
+
+func WithBatchMaxBytes(max int) OptionHandler {
+	return func(opt *Options) {
+		opt.BatchMaxBytes = max
+	}
+}
+
+func WithBatchFormat(format BatchFormat) OptionHandler {
+	return func(opt *Options) {
+		opt.BatchFormat = format
+	}
+}
+
+func WithRetry(max int, backoff, maxBackoff time.Duration) OptionHandler {
+	return func(opt *Options) {
+		opt.RetryMax = max
+		opt.RetryBackoff = backoff
+		opt.RetryMaxBackoff = maxBackoff
+	}
+}
+
+// WithHTTPHeader sets a header on each request.
+func WithHTTPHeader(key, value string) OptionHandler {
+	return func(opt *Options) {
+		headers := make(map[string]string, len(opt.HTTPHeaders)+1)
+		for k, v := range opt.HTTPHeaders {
+			headers[k] = v
+		}
+		headers[key] = value
+		opt.HTTPHeaders = headers
+	}
+}
+
+func WithHTTPClient(client *http.Client) OptionHandler {
+	return func(opt *Options) {
+		opt.HTTPClient = client
+	}
+}
+
+func WithSpool(dir string, maxBytes int64) OptionHandler {
+	return func(opt *Options) {
+		opt.SpoolDir = dir
+		opt.SpoolMaxBytes = maxBytes
+	}
+}
+
+func WithFluentTagPrefix(prefix string) OptionHandler {
+	return func(opt *Options) {
+		opt.FluentTagPrefix = prefix
+	}
+}
+
+func WithFluentMode(mode FluentMode) OptionHandler {
+	return func(opt *Options) {
+		opt.FluentMode = mode
+	}
+}
+
+func WithFluentAck(ack bool, timeout time.Duration) OptionHandler {
+	return func(opt *Options) {
+		opt.FluentAck = ack
+		opt.FluentAckTimeout = timeout
+	}
+}
+
+func WithLokiLabels(keys ...string) OptionHandler {
+	return func(opt *Options) {
+		opt.LokiLabels = keys
+	}
+}
+
+func WithLokiFormat(format LokiFormat) OptionHandler {
+	return func(opt *Options) {
+		opt.LokiFormat = format
+	}
+}
+
+func WithLokiMaxLabelValues(max int) OptionHandler {
+	return func(opt *Options) {
+		opt.LokiMaxLabelValues = max
+	}
+}
+
 func WithLevel(level string) OptionHandler {
 	l := strings.ToLower(level)
 	return func(opt *Options) {
@@ -262,6 +954,22 @@ func DefaultOption() Options {
 		MaxBackups:          0,
 		LocalTime:           false,
 		Compress:            false,
+		FileMode:            0600,
+		FileUID:             -1,
+		FileGID:             -1,
+		LowDiskLevel:        InfoLevel,
+		DialTimeout:         5 * time.Second,
+		BatchSize:           100,
+		BatchInterval:       time.Second,
+		BatchMaxBytes:       8 << 20,
+		RetryMax:            5,
+		RetryBackoff:        500 * time.Millisecond,
+		RetryMaxBackoff:     30 * time.Second,
+		SpoolMaxBytes:       64 << 20,
+		FluentAckTimeout:    10 * time.Second,
+		LokiLabels:          []string{"level"},
+		LokiMaxLabelValues:  100,
+		SyslogFacility:      FacilityUser,
 		MessageKey:          "msg",
 		LevelKey:            "level",
 		TimeKey:             "time",
@@ -0,0 +1,353 @@
+package log4go
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// PatternEncoding writes each entry formatted by the log4j style layout
+// configured with WithPattern.
+const PatternEncoding = "pattern"
+
+// DefaultPattern is the layout used by the pattern encoding when no pattern
+// is configured.
+const DefaultPattern = "%d{2006-01-02 15:04:05.000} [%-5p] %c %F:%L - %m %X%n"
+
+// patternConverter renders a single conversion of the pattern for an entry.
+type patternConverter func(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string
+
+// patternConverters maps the conversion names to their converter, names are
+// matched longest first so that "%msg" is not read as "%m" followed by "sg".
+var patternConverters = map[string]patternConverter{
+	"d":          convertDate,
+	"date":       convertDate,
+	"p":          convertLevel,
+	"level":      convertLevel,
+	"c":          convertLogger,
+	"logger":     convertLogger,
+	"F":          convertFile,
+	"file":       convertFile,
+	"L":          convertLine,
+	"line":       convertLine,
+	"M":          convertFunction,
+	"method":     convertFunction,
+	"l":          convertLocation,
+	"location":   convertLocation,
+	"m":          convertMessage,
+	"msg":        convertMessage,
+	"message":    convertMessage,
+	"X":          convertFields,
+	"mdc":        convertFields,
+	"ex":         convertStack,
+	"throwable":  convertStack,
+	"stacktrace": convertStack,
+}
+
+// patternToken is a literal text, or a conversion with its format modifiers.
+type patternToken struct {
+	literal   string
+	convert   patternConverter
+	arg       string
+	leftAlign bool
+	minWidth  int
+	maxWidth  int
+}
+
+// NewPatternEncoder creates an encoder which formats each entry by a log4j
+// style pattern, such as
+//
+//	%d{2006-01-02 15:04:05.000} [%-5p] %c{1} %F:%L - %m %X{request_id}%n
+//
+// The supported conversions are:
+//
+//	%d, %date            time, formatted by {layout} in Go layout if given
+//	%p, %level           level
+//	%c, %logger          logger name, or its last {n} dot separated parts
+//	%F, %file            base name of the caller file
+//	%L, %line            caller line
+//	%M, %method          caller function
+//	%l, %location        caller, as encoded by the caller encoder
+//	%m, %msg, %message   message
+//	%X, %mdc             the field named {key}, or all fields in logfmt
+//	%ex, %throwable      stack trace, prefixed with a newline if not empty
+//	%n                   newline
+//	%%                   a literal percent sign
+//
+// A conversion may be preceded by a format modifier: %-5p left aligns the
+// level in 5 columns, %5p right aligns it, and %.10c truncates the logger
+// name to its last 10 characters, as log4j does. If the pattern has no %n,
+// the line ending is appended.
+//
+// Named fields are looked up in the fields of the entry, fields added to the
+// encoder through With appear only in %X.
+func NewPatternEncoder(pattern string, cfg EncoderConfig) (Encoder, error) {
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	}
+	tokens, hasNewline, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if !hasNewline && cfg.LineEnding != "" {
+		tokens = append(tokens, patternToken{literal: cfg.LineEnding})
+	}
+	return &patternEncoder{
+		logfmtEncoder: &logfmtEncoder{cfg: &cfg, buf: _logfmtPool.Get()},
+		tokens:        tokens,
+	}, nil
+}
+
+// parsePattern splits the pattern into tokens, and reports whether it holds
+// a %n conversion.
+func parsePattern(pattern string) ([]patternToken, bool, error) {
+	var (
+		tokens     []patternToken
+		literal    strings.Builder
+		hasNewline bool
+	)
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, patternToken{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(pattern); {
+		if pattern[i] != '%' {
+			literal.WriteByte(pattern[i])
+			i++
+			continue
+		}
+		i++
+		if i >= len(pattern) {
+			return nil, false, fmt.Errorf("log4go: pattern %q ends with a single %%", pattern)
+		}
+		switch pattern[i] {
+		case '%':
+			literal.WriteByte('%')
+			i++
+			continue
+		case 'n':
+			literal.WriteByte('\n')
+			hasNewline = true
+			i++
+			continue
+		}
+
+		var tok patternToken
+		// format modifiers
+		if pattern[i] == '-' {
+			tok.leftAlign = true
+			i++
+		}
+		start := i
+		for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+			i++
+		}
+		if i > start {
+			tok.minWidth, _ = strconv.Atoi(pattern[start:i])
+		}
+		if i < len(pattern) && pattern[i] == '.' {
+			i++
+			start = i
+			for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+				i++
+			}
+			if i == start {
+				return nil, false, fmt.Errorf("log4go: missing maximum width at %d in pattern %q", start, pattern)
+			}
+			tok.maxWidth, _ = strconv.Atoi(pattern[start:i])
+		}
+
+		// conversion name, the longest known one
+		name := ""
+		for n := range patternConverters {
+			if len(n) > len(name) && strings.HasPrefix(pattern[i:], n) {
+				name = n
+			}
+		}
+		if name == "" {
+			return nil, false, fmt.Errorf("log4go: unknown conversion at %d in pattern %q", i, pattern)
+		}
+		tok.convert = patternConverters[name]
+		i += len(name)
+
+		// conversion argument
+		if i < len(pattern) && pattern[i] == '{' {
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, false, fmt.Errorf("log4go: unclosed { at %d in pattern %q", i, pattern)
+			}
+			tok.arg = pattern[i+1 : i+end]
+			i += end + 1
+		}
+		flush()
+		tokens = append(tokens, tok)
+	}
+	flush()
+	return tokens, hasNewline, nil
+}
+
+// patternEncoder formats the entries by the tokens of the pattern, the
+// fields added through With are kept by the embedded logfmt encoder.
+type patternEncoder struct {
+	*logfmtEncoder
+	tokens []patternToken
+}
+
+func (p *patternEncoder) Clone() zapcore.Encoder {
+	return &patternEncoder{
+		logfmtEncoder: p.logfmtEncoder.clone(),
+		tokens:        p.tokens,
+	}
+}
+
+func (p *patternEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := _logfmtPool.Get()
+	for _, tok := range p.tokens {
+		if tok.convert == nil {
+			line.AppendString(tok.literal)
+			continue
+		}
+		s := tok.convert(p, ent, fields, tok.arg)
+		if tok.maxWidth > 0 {
+			if n := utf8.RuneCountInString(s); n > tok.maxWidth {
+				s = string([]rune(s)[n-tok.maxWidth:])
+			}
+		}
+		pad := tok.minWidth - utf8.RuneCountInString(s)
+		if pad > 0 && !tok.leftAlign {
+			line.AppendString(strings.Repeat(" ", pad))
+		}
+		line.AppendString(s)
+		if pad > 0 && tok.leftAlign {
+			line.AppendString(strings.Repeat(" ", pad))
+		}
+	}
+	return line, nil
+}
+
+// primitive returns the value appended by fn as a string.
+func (p *patternEncoder) primitive(fn func(zapcore.PrimitiveArrayEncoder)) string {
+	arr := &logfmtArrayEncoder{cfg: p.cfg, buf: _logfmtPool.Get()}
+	defer arr.buf.Free()
+	fn(arr)
+	return arr.buf.String()
+}
+
+func convertDate(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if arg != "" {
+		return ent.Time.Format(arg)
+	}
+	if p.cfg.EncodeTime == nil {
+		return ent.Time.Format("2006-01-02 15:04:05")
+	}
+	return p.primitive(func(enc zapcore.PrimitiveArrayEncoder) {
+		p.cfg.EncodeTime(ent.Time, enc)
+	})
+}
+
+func convertLevel(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if p.cfg.EncodeLevel == nil {
+		return ent.Level.String()
+	}
+	return p.primitive(func(enc zapcore.PrimitiveArrayEncoder) {
+		p.cfg.EncodeLevel(ent.Level, enc)
+	})
+}
+
+func convertLogger(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n <= 0 {
+		return ent.LoggerName
+	}
+	parts := strings.Split(ent.LoggerName, ".")
+	if len(parts) <= n {
+		return ent.LoggerName
+	}
+	return strings.Join(parts[len(parts)-n:], ".")
+}
+
+func convertFile(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if !ent.Caller.Defined {
+		return ""
+	}
+	return filepath.Base(ent.Caller.File)
+}
+
+func convertLine(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if !ent.Caller.Defined {
+		return ""
+	}
+	return strconv.Itoa(ent.Caller.Line)
+}
+
+func convertFunction(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if !ent.Caller.Defined {
+		return ""
+	}
+	return ent.Caller.Function
+}
+
+func convertLocation(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if !ent.Caller.Defined {
+		return ""
+	}
+	if p.cfg.EncodeCaller == nil {
+		return ent.Caller.String()
+	}
+	return p.primitive(func(enc zapcore.PrimitiveArrayEncoder) {
+		p.cfg.EncodeCaller(ent.Caller, enc)
+	})
+}
+
+func convertMessage(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	return ent.Message
+}
+
+func convertStack(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	if ent.Stack == "" {
+		return ""
+	}
+	return "\n" + ent.Stack
+}
+
+// convertFields renders the field named by arg, or all the fields in logfmt
+// if arg is empty.
+func convertFields(p *patternEncoder, ent zapcore.Entry, fields []zapcore.Field, arg string) string {
+	enc := &logfmtEncoder{cfg: p.cfg, buf: _logfmtPool.Get()}
+	defer enc.buf.Free()
+	if arg == "" {
+		enc.buf.Write(p.buf.Bytes())
+		enc.prefix = p.prefix
+		for i := range fields {
+			fields[i].AddTo(enc)
+		}
+		return enc.buf.String()
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key != arg {
+			continue
+		}
+		if fields[i].Type == zapcore.StringType {
+			return fields[i].String
+		}
+		fields[i].AddTo(enc)
+		s := enc.buf.String()
+		key := _logfmtPool.Get()
+		writeLogfmtKey(key, arg)
+		key.AppendByte('=')
+		s = strings.TrimPrefix(s, key.String())
+		key.Free()
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPatternEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wlog := NewWriterLogger(buf,
+		WithPattern("%d{2006} [%-5p] %c{1} %F:%L %.4m %X{request_id}|%X%n"),
+		WithName("app.http"),
+		WithStack(false),
+		WithLevelEncoder(CapitalLevelEncoder))
+
+	before := time.Now()
+	_, _, line, _ := runtime.Caller(0)
+	wlog.Info(context.TODO(), "hello pattern", String("request_id", "r1"), Int("n", 2))
+	after := time.Now()
+
+	// the year is the one of the entry, which may have changed meanwhile
+	year, err := strconv.Atoi(buf.String()[:4])
+	if err != nil || year < before.Year() || year > after.Year() {
+		t.Fatalf("unexpected year in %q", buf.String())
+	}
+	want := fmt.Sprintf("%d [INFO ] http pattern_test.go:%d tern r1|request_id=r1 n=2\n", year, line+1)
+	if buf.String() != want {
+		t.Errorf("pattern output\n got: %q\nwant: %q", buf.String(), want)
+	}
+
+	for _, pattern := range []string{"%", "%q", "%d{2006", "%.p"} {
+		if _, err := NewPatternEncoder(pattern, EncoderConfig{}); err == nil {
+			t.Errorf("pattern %q should be rejected", pattern)
+		}
+	}
+}
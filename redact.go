@@ -0,0 +1,253 @@
+package log4go
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactStrategy is how a redacted value is written.
+type RedactStrategy int
+
+const (
+	// RedactReplace replaces the value with "***".
+	RedactReplace RedactStrategy = iota
+	// RedactDrop removes the field, or the matched part of a string.
+	RedactDrop
+	// RedactHash replaces the value with "sha256:" followed by the hex
+	// SHA-256 of RedactSalt and the value, so that equal values can be
+	// correlated.
+	RedactHash
+	// RedactLast4 keeps the last 4 characters of the value, e.g. "***1234".
+	RedactLast4
+)
+
+const redactMask = "***"
+
+// Value patterns for RedactValue.
+var (
+	// RedactCreditCard matches card numbers of 13 to 19 digits, optionally
+	// grouped by spaces or dashes.
+	RedactCreditCard = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	// RedactEmail matches email addresses.
+	RedactEmail = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// RedactJWT matches JSON Web Tokens.
+	RedactJWT = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// RedactRule selects the values to redact, by the key of their field, at
+// any depth of nested objects, or by a pattern found in string values.
+type RedactRule struct {
+	// Key is a glob matching the keys case insensitively, such as
+	// "*password*" or "authorization".
+	Key string
+	// KeyRegexp matches the keys.
+	KeyRegexp *regexp.Regexp
+	// Value matches the parts of string values to redact, including the
+	// message.
+	Value    *regexp.Regexp
+	Strategy RedactStrategy
+}
+
+// RedactKey returns the rule redacting the values of the keys matching the
+// glob.
+func RedactKey(glob string, s RedactStrategy) RedactRule {
+	return RedactRule{Key: glob, Strategy: s}
+}
+
+// RedactKeyRegexp returns the rule redacting the values of the keys
+// matching re.
+func RedactKeyRegexp(re *regexp.Regexp, s RedactStrategy) RedactRule {
+	return RedactRule{KeyRegexp: re, Strategy: s}
+}
+
+// RedactValue returns the rule redacting the parts of the string values
+// matching re.
+func RedactValue(re *regexp.Regexp, s RedactStrategy) RedactRule {
+	return RedactRule{Value: re, Strategy: s}
+}
+
+// redactor applies the RedactRules of the options.
+type redactor struct {
+	keys   []RedactRule
+	values []RedactRule
+	salt   []byte
+}
+
+func newRedactor(opts Options) *redactor {
+	r := &redactor{salt: opts.RedactSalt}
+	for _, rule := range opts.RedactRules {
+		if rule.Key != "" || rule.KeyRegexp != nil {
+			rule.Key = strings.ToLower(rule.Key)
+			r.keys = append(r.keys, rule)
+		}
+		if rule.Value != nil {
+			r.values = append(r.values, rule)
+		}
+	}
+	return r
+}
+
+// keyRule returns the first rule matching key.
+func (r *redactor) keyRule(key string) (RedactRule, bool) {
+	lower := strings.ToLower(key)
+	for _, rule := range r.keys {
+		if rule.Key != "" {
+			if ok, _ := path.Match(rule.Key, lower); ok {
+				return rule, true
+			}
+		}
+		if rule.KeyRegexp != nil && rule.KeyRegexp.MatchString(key) {
+			return rule, true
+		}
+	}
+	return RedactRule{}, false
+}
+
+func (r *redactor) transform(ent *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	ent.Message, _ = r.redactString(ent.Message)
+	out := fields[:0]
+	for _, f := range fields {
+		if rule, ok := r.keyRule(f.Key); ok {
+			if rule.Strategy != RedactDrop {
+				out = append(out, zap.String(f.Key, r.apply(rule.Strategy, stringValue(fieldValue(f)))))
+			}
+			continue
+		}
+		switch f.Type {
+		case zapcore.StringType:
+			f.String, _ = r.redactString(f.String)
+		case zapcore.ByteStringType, zapcore.ErrorType, zapcore.StringerType,
+			zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+			v := fieldValue(f)
+			if f.Type == zapcore.ObjectMarshalerType || f.Type == zapcore.ArrayMarshalerType || f.Type == zapcore.ReflectType {
+				v = genericValue(v)
+			}
+			if v, changed := r.redactValue(v); changed {
+				f = zap.Any(f.Key, v)
+			}
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// redactValue redacts the generic value v, made of maps, slices, strings
+// and other scalars, reporting whether it changed.
+func (r *redactor) redactValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		return r.redactString(v)
+	case []byte:
+		return r.redactString(string(v))
+	case map[string]interface{}:
+		changed := false
+		for k, x := range v {
+			if rule, ok := r.keyRule(k); ok {
+				if rule.Strategy == RedactDrop {
+					delete(v, k)
+				} else {
+					v[k] = r.apply(rule.Strategy, stringValue(x))
+				}
+				changed = true
+				continue
+			}
+			if x, ok := r.redactValue(x); ok {
+				v[k] = x
+				changed = true
+			}
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, x := range v {
+			if x, ok := r.redactValue(x); ok {
+				v[i] = x
+				changed = true
+			}
+		}
+		return v, changed
+	}
+	return v, false
+}
+
+// redactString replaces the parts of s matching the value rules.
+func (r *redactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, rule := range r.values {
+		if !rule.Value.MatchString(s) {
+			continue
+		}
+		s = rule.Value.ReplaceAllStringFunc(s, func(m string) string {
+			if rule.Strategy == RedactDrop {
+				return ""
+			}
+			return r.apply(rule.Strategy, m)
+		})
+		changed = true
+	}
+	return s, changed
+}
+
+// apply returns the redacted form of s.
+func (r *redactor) apply(strategy RedactStrategy, s string) string {
+	switch strategy {
+	case RedactHash:
+		h := sha256.New()
+		h.Write(r.salt)
+		h.Write([]byte(s))
+		return "sha256:" + hex.EncodeToString(h.Sum(nil))
+	case RedactLast4:
+		if runes := []rune(s); len(runes) > 4 {
+			return redactMask + string(runes[len(runes)-4:])
+		}
+	}
+	return redactMask
+}
+
+// fieldValue returns the value of f as encoded: objects as maps, arrays as
+// slices, errors and stringers as strings.
+func fieldValue(f zapcore.Field) interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return enc.Fields[f.Key]
+}
+
+// genericValue converts v to maps, slices and scalars through JSON, so that
+// redacting it doesn't modify the value of the caller.
+func genericValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var g interface{}
+	if err := dec.Decode(&g); err != nil {
+		return string(data)
+	}
+	return g
+}
+
+// stringValue returns v as a string, in JSON unless a string.
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
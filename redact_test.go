@@ -0,0 +1,54 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	nested := map[string]interface{}{"user": "bob", "headers": map[string]interface{}{"Authorization": "Bearer abc"}}
+	wlog := NewWriterLogger(&buf, WithStack(false), WithCaller(false),
+		WithExtendFields(String("db_password", "hunter2")),
+		WithRedaction(
+			RedactKey("*password*", RedactDrop),
+			RedactKey("authorization", RedactReplace),
+			RedactKeyRegexp(regexp.MustCompile(`^card$`), RedactLast4),
+			RedactKey("session", RedactHash),
+			RedactValue(RedactEmail, RedactReplace),
+			RedactValue(RedactJWT, RedactReplace),
+		), WithRedactSalt([]byte("salt")))
+
+	ctx := context.WithValue(context.TODO(), ContextFieldsKey, []Field{String("contact", "mail bob@example.com")})
+	wlog.Info(ctx, "token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln issued",
+		String("card", "4111111111111111"), String("session", "s1"), Field(zap.Any("req", nested)))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	if _, ok := entry["db_password"]; ok {
+		t.Error("password not dropped")
+	}
+	if entry["msg"] != "token *** issued" || entry["card"] != "***1111" || entry["contact"] != "mail ***" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+	if s, _ := entry["session"].(string); !strings.HasPrefix(s, "sha256:") || strings.Contains(s, "s1") {
+		t.Errorf("session hashed as %q", s)
+	}
+	req, _ := entry["req"].(map[string]interface{})
+	headers, _ := req["headers"].(map[string]interface{})
+	if req["user"] != "bob" || headers["Authorization"] != "***" {
+		t.Errorf("nested object redacted as %v", req)
+	}
+	// the value of the caller is left untouched
+	if nested["headers"].(map[string]interface{})["Authorization"] != "Bearer abc" {
+		t.Error("caller value modified")
+	}
+}
@@ -0,0 +1,30 @@
+package log4go
+
+// Reopen reopens the files of the default logger and of the loggers set by
+// SetLogger which implement Reopener.
+func Reopen() error {
+	loggerMutex.Lock()
+	targets := make([]Logger, 0, len(loggers)+1)
+	if defaultLogger != nil {
+		targets = append(targets, defaultLogger)
+	}
+	for _, l := range loggers {
+		targets = append(targets, l)
+	}
+	loggerMutex.Unlock()
+	return reopenAll(targets)
+}
+
+// reopenAll reopens the loggers implementing Reopener, and returns the
+// first error.
+func reopenAll(targets []Logger) error {
+	var firstErr error
+	for _, l := range targets {
+		if r, ok := l.(Reopener); ok {
+			if err := r.Reopen(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
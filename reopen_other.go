@@ -0,0 +1,12 @@
+//go:build js || plan9 || windows
+// +build js plan9 windows
+
+package log4go
+
+import "os"
+
+// HandleReopenSignal does nothing on the systems without SIGHUP, call Reopen
+// instead. It returns a function doing nothing.
+func HandleReopenSignal(sigs ...os.Signal) (stop func()) {
+	return func() {}
+}
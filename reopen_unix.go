@@ -0,0 +1,47 @@
+//go:build !js && !plan9 && !windows
+// +build !js,!plan9,!windows
+
+package log4go
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// HandleReopenSignal calls Reopen whenever the process receives one of the
+// signals, SIGHUP if none given, as expected by logrotate configurations
+// using "create" with a postrotate "kill -HUP". It returns a function
+// stopping the handling.
+func HandleReopenSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go reopenOnSignal(ch, done)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+func reopenOnSignal(ch <-chan os.Signal, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-ch:
+			if err := Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "log4go: reopen failed: %v\n", err)
+			}
+		}
+	}
+}
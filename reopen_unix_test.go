@@ -0,0 +1,57 @@
+//go:build !js && !plan9 && !windows
+// +build !js,!plan9,!windows
+
+package log4go
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	flog := NewFileLogger(WithFileName(name))
+	defer flog.Close()
+	SetLogger("reopen-test", NewGroupLogger(flog))
+	defer func() {
+		loggerMutex.Lock()
+		delete(loggers, "reopen-test")
+		loggerMutex.Unlock()
+	}()
+
+	flog.Info(context.TODO(), "before")
+	// logrotate renames the file, then signals the process
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go reopenOnSignal(ch, done)
+	defer close(done)
+	ch <- syscall.SIGHUP
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(name); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file not reopened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	flog.Info(context.TODO(), "after")
+
+	rotated, _ := os.ReadFile(name + ".1")
+	current, _ := os.ReadFile(name)
+	if !strings.Contains(string(rotated), `"msg":"before"`) || strings.Contains(string(rotated), `"msg":"after"`) ||
+		!strings.Contains(string(current), `"msg":"after"`) {
+		t.Errorf("rotated file holds %s, current file holds %s", rotated, current)
+	}
+}
@@ -0,0 +1,628 @@
+package log4go
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	megabyte = 1024 * 1024
+	// backupTimeFormat is the timestamp inserted in the names of the
+	// rotated files.
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	// backupStamp matches the timestamp of backupTimeFormat, and the
+	// counter of backupName.
+	backupStamp = `-([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}-[0-9]{2}-[0-9]{2}\.[0-9]{3})(?:-[0-9]+)?`
+)
+
+// RotateReason tells why the log file was rotated.
+type RotateReason string
+
+const (
+	RotateSize   RotateReason = "size"
+	RotateTime   RotateReason = "time"
+	RotateManual RotateReason = "manual"
+)
+
+// RotateEvent describes a rotation of the log file.
+type RotateEvent struct {
+	// Previous is the path of the closed file, after it was renamed.
+	Previous string
+	// Current is the path of the file opened.
+	Current string
+	Time    time.Time
+	Reason  RotateReason
+}
+
+// RotatingWriter writes to a log file which is rotated when it reaches
+// MaxSize megabytes, and at the times of the RotationPolicy.
+//
+// Unless the name follows FilenamePattern, a rotated file is renamed by
+// inserting a timestamp before its extension, e.g. app-2006-01-02T15-04-05.000.log,
+// and a new file is opened under the same name. After each rotation, the
+// rotated files are compressed and removed according to MaxBackups, MaxAge
+// and MaxTotalSize in the background, then the DiskBudget shared with other
+// writers is enforced.
+//
+// With Encryption, each opening of the file starts a new encrypted segment,
+// read back by DecryptReader, and MaxSize counts the bytes before
+// encryption.
+type RotatingWriter struct {
+	filename   string
+	pattern    string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	maxTotal   int64
+	localTime  bool
+	mode       os.FileMode
+	uid, gid   int
+	link       string
+	policy     RotationPolicy
+	compressor Compressor
+	callbacks  []func(RotateEvent)
+	clock      Clock
+	fs         FileSystem
+	budget     *DiskBudget
+	fsyncEvery int
+	fsyncAfter time.Duration
+	noFsync    bool
+	keys       KeyProvider
+	backupRe   *regexp.Regexp
+
+	mu   sync.Mutex
+	file File
+	name string
+	size int64
+	next time.Time
+	// pending counts the entries written since the last fsync, timer
+	// fsyncs them after fsyncAfter.
+	pending int
+	timer   *time.Timer
+
+	millMu sync.Mutex
+	millWG sync.WaitGroup
+}
+
+// NewRotatingWriter create a new RotatingWriter configured by the file
+// options: Filename or FilenamePattern, MaxSize, RotationPolicy,
+// MaxBackups, MaxAge, MaxTotalSize, Compress or Compressor, FileMode,
+// FileUID and FileGID, CurrentLink, RotateCallbacks, DiskBudget,
+// FsyncEvery, FsyncInterval, DisableFsync and Encryption. The file is
+// opened on the first write.
+func NewRotatingWriter(oh ...OptionHandler) *RotatingWriter {
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	return newRotatingWriter(opts)
+}
+
+func newRotatingWriter(opts Options) *RotatingWriter {
+	w := &RotatingWriter{
+		filename:   opts.Filename,
+		pattern:    opts.FilenamePattern,
+		maxSize:    int64(opts.MaxSize) * megabyte,
+		maxBackups: opts.MaxBackups,
+		maxAge:     time.Duration(opts.MaxAge) * 24 * time.Hour,
+		maxTotal:   int64(opts.MaxTotalSize) * megabyte,
+		localTime:  opts.LocalTime,
+		mode:       opts.FileMode,
+		uid:        opts.FileUID,
+		gid:        opts.FileGID,
+		link:       opts.CurrentLink,
+		policy:     opts.RotationPolicy,
+		compressor: opts.Compressor,
+		callbacks:  opts.RotateCallbacks,
+		clock:      opts.Clock,
+		fs:         opts.FileSystem,
+		budget:     opts.DiskBudget,
+		fsyncEvery: opts.FsyncEvery,
+		fsyncAfter: opts.FsyncInterval,
+		noFsync:    opts.DisableFsync,
+		keys:       opts.Encryption,
+	}
+	if w.filename == "" {
+		w.filename = filepath.Join(os.TempDir(), filepath.Base(os.Args[0])+"-log4go.log")
+	}
+	w.backupRe = backupRegexp(w.filename, w.pattern)
+	if w.compressor == nil && opts.Compress {
+		w.compressor = GzipCompressor{}
+	}
+	if w.mode == 0 {
+		w.mode = 0600
+	}
+	if w.clock == nil {
+		w.clock = systemClock{}
+	}
+	if w.fs == nil {
+		w.fs = OSFileSystem{}
+	}
+	if w.budget != nil {
+		w.budget.add(w)
+	}
+	return w
+}
+
+// now returns the current time in the location of the file times.
+func (w *RotatingWriter) now() time.Time {
+	if w.localTime {
+		return w.clock.Now().Local()
+	}
+	return w.clock.Now().UTC()
+}
+
+// currentName returns the name of the file of the period including t.
+func (w *RotatingWriter) currentName(t time.Time) string {
+	if w.pattern != "" {
+		return formatFilename(w.pattern, t)
+	}
+	return w.filename
+}
+
+// Filename returns the name of the file written, empty before the first
+// write.
+func (w *RotatingWriter) Filename() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.name
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	if w.file == nil {
+		if err := w.openExisting(now); err != nil {
+			return 0, err
+		}
+	}
+	var err error
+	switch {
+	case !w.next.IsZero() && !now.Before(w.next):
+		err = w.rotate(now, RotateTime)
+	case w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize:
+		err = w.rotate(now, RotateSize)
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if n > 0 {
+		w.pending++
+		switch {
+		case w.fsyncEvery > 0 && w.pending >= w.fsyncEvery:
+			if err := w.fsync(); err != nil {
+				fmt.Fprintf(os.Stderr, "log4go: fsync %s failed: %v\n", w.name, err)
+			}
+		case w.fsyncAfter > 0 && w.timer == nil:
+			w.timer = time.AfterFunc(w.fsyncAfter, w.fsyncPending)
+		}
+	}
+	return n, err
+}
+
+// fsync commits the file to stable storage, unless DisableFsync is set.
+func (w *RotatingWriter) fsync() error {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.pending = 0
+	if w.file == nil || w.noFsync {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// fsyncPending commits the entries written since the last fsync, after
+// FsyncInterval.
+func (w *RotatingWriter) fsyncPending() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	if w.pending == 0 {
+		return
+	}
+	if err := w.fsync(); err != nil {
+		fmt.Fprintf(os.Stderr, "log4go: fsync %s failed: %v\n", w.name, err)
+	}
+}
+
+// closeFile closes the file, after committing the pending entries if
+// FsyncEvery or FsyncInterval is set.
+func (w *RotatingWriter) closeFile() error {
+	if w.pending > 0 && (w.fsyncEvery > 0 || w.fsyncAfter > 0) {
+		if err := w.fsync(); err != nil {
+			fmt.Fprintf(os.Stderr, "log4go: fsync %s failed: %v\n", w.name, err)
+		}
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.pending = 0
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// openExisting opens the file of the current period, appending to it if it
+// exists, unless it was last written in a past rotation period.
+func (w *RotatingWriter) openExisting(now time.Time) error {
+	if w.budget != nil {
+		// registered again after Close
+		w.budget.add(w)
+	}
+	name := w.currentName(now)
+	info, err := w.fs.Stat(name)
+	if err != nil {
+		return w.openNew(name, now)
+	}
+	if w.policy != nil {
+		if next := w.policy.Next(w.inLocation(info.ModTime())); !next.IsZero() && !next.After(now) {
+			if err := w.fs.Rename(name, w.backupName(name, info.ModTime())); err != nil {
+				return err
+			}
+			w.startMill()
+			return w.openNew(name, now)
+		}
+	}
+	f, err := w.fs.OpenFile(name, os.O_WRONLY|os.O_APPEND, w.mode)
+	if err != nil {
+		return w.openNew(name, now)
+	}
+	w.file, w.name, w.size = w.wrap(f), name, info.Size()
+	w.schedule(now)
+	w.updateLink()
+	return nil
+}
+
+// wrap encrypts the writes to f if Encryption is set.
+func (w *RotatingWriter) wrap(f File) File {
+	if w.keys == nil {
+		return f
+	}
+	return newEncryptFile(f, w.keys)
+}
+
+// openNew creates the file, truncating it if it exists.
+func (w *RotatingWriter) openNew(name string, now time.Time) error {
+	if err := w.fs.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := w.fs.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, w.mode)
+	if err != nil {
+		return err
+	}
+	if w.uid >= 0 || w.gid >= 0 {
+		if err := w.fs.Chown(name, w.uid, w.gid); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	w.file, w.name, w.size = w.wrap(f), name, 0
+	w.schedule(now)
+	w.updateLink()
+	return nil
+}
+
+func (w *RotatingWriter) schedule(now time.Time) {
+	w.next = time.Time{}
+	if w.policy != nil {
+		w.next = w.policy.Next(now)
+	}
+}
+
+func (w *RotatingWriter) inLocation(t time.Time) time.Time {
+	if w.localTime {
+		return t.Local()
+	}
+	return t.UTC()
+}
+
+// updateLink points CurrentLink to the file, replacing the link atomically.
+func (w *RotatingWriter) updateLink() {
+	if w.link == "" {
+		return
+	}
+	target := w.name
+	if abs, err := filepath.Abs(w.name); err == nil {
+		target = abs
+	}
+	tmp := w.link + ".tmp"
+	w.fs.Remove(tmp)
+	if err := w.fs.Symlink(target, tmp); err != nil {
+		fmt.Fprintf(os.Stderr, "log4go: link %s to %s failed: %v\n", w.link, target, err)
+		return
+	}
+	if err := w.fs.Rename(tmp, w.link); err != nil {
+		w.fs.Remove(tmp)
+		fmt.Fprintf(os.Stderr, "log4go: link %s to %s failed: %v\n", w.link, target, err)
+	}
+}
+
+// rotate closes the file and opens the file of the current period, after
+// renaming the closed file if both have the same name.
+func (w *RotatingWriter) rotate(now time.Time, reason RotateReason) error {
+	prev := w.name
+	if err := w.closeFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "log4go: close %s failed: %v\n", prev, err)
+	}
+	name := w.currentName(now)
+	if name == prev {
+		prev = w.backupName(prev, now)
+		if err := w.fs.Rename(name, prev); err != nil {
+			return err
+		}
+	}
+	if err := w.openNew(name, now); err != nil {
+		return err
+	}
+	for _, fn := range w.callbacks {
+		fn(RotateEvent{Previous: prev, Current: name, Time: now, Reason: reason})
+	}
+	w.startMill()
+	return nil
+}
+
+// backupName returns the name of the rotated file, unique in its directory.
+func (w *RotatingWriter) backupName(name string, t time.Time) string {
+	dir, base := filepath.Split(name)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)]
+	stamp := w.inLocation(t).Format(backupTimeFormat)
+	backup := filepath.Join(dir, prefix+"-"+stamp+ext)
+	for i := 1; w.exists(backup); i++ {
+		backup = filepath.Join(dir, prefix+"-"+stamp+"-"+strconv.Itoa(i)+ext)
+	}
+	return backup
+}
+
+func (w *RotatingWriter) exists(name string) bool {
+	if _, err := w.fs.Lstat(name); err == nil {
+		return true
+	}
+	if w.compressor != nil {
+		if _, err := w.fs.Lstat(name + w.compressor.Extension()); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Rotate closes the file and opens a new one.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	if w.file == nil {
+		return w.openExisting(now)
+	}
+	return w.rotate(now, RotateManual)
+}
+
+// Reopen closes the file and opens it again by name, so that after an
+// external tool such as logrotate renamed the file, entries go to a new file
+// under the configured name. Concurrent writes wait for the file to be
+// opened again.
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		if err := w.closeFile(); err != nil {
+			fmt.Fprintf(os.Stderr, "log4go: close %s failed: %v\n", w.name, err)
+		}
+	}
+	return w.openExisting(w.now())
+}
+
+// Sync commits the file to stable storage, unless DisableFsync is set.
+func (w *RotatingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.fsync()
+}
+
+// Close closes the file, waits for the background compression and removal
+// of the rotated files, and leaves the DiskBudget.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.closeFile()
+	}
+	w.mu.Unlock()
+	w.millWG.Wait()
+	if w.budget != nil {
+		w.budget.remove(w)
+	}
+	return err
+}
+
+// startMill compresses and removes the rotated files in the background.
+func (w *RotatingWriter) startMill() {
+	if w.compressor == nil && w.maxBackups <= 0 && w.maxAge <= 0 && w.maxTotal <= 0 && w.budget == nil {
+		return
+	}
+	current := w.name
+	w.millWG.Add(1)
+	go func() {
+		defer w.millWG.Done()
+		w.millMu.Lock()
+		defer w.millMu.Unlock()
+		if err := w.mill(current); err != nil {
+			fmt.Fprintf(os.Stderr, "log4go: clean up rotated files of %s failed: %v\n", current, err)
+		}
+		if w.budget != nil {
+			if err := w.budget.Enforce(); err != nil {
+				fmt.Fprintf(os.Stderr, "log4go: enforce disk budget failed: %v\n", err)
+			}
+		}
+	}()
+}
+
+// mill removes the rotated files beyond MaxBackups, older than MaxAge, or
+// beyond MaxTotalSize from the newest, and compresses the others.
+func (w *RotatingWriter) mill(current string) error {
+	backups, err := w.backups(current)
+	if err != nil {
+		return err
+	}
+	cutoff := w.clock.Now().Add(-w.maxAge)
+	var total int64
+	var firstErr error
+	for i, b := range backups {
+		total += b.Size()
+		path := filepath.Join(filepath.Dir(current), b.Name())
+		if w.isLive(path) {
+			continue
+		}
+		if (w.maxBackups > 0 && i >= w.maxBackups) || (w.maxAge > 0 && b.ModTime().Before(cutoff)) ||
+			(w.maxTotal > 0 && total > w.maxTotal) {
+			if err := w.fs.Remove(path); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if w.compressor != nil && !strings.HasSuffix(b.Name(), w.compressor.Extension()) {
+			if err := w.compress(path, b); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// isLive reports whether path is the file being written, which may have
+// been opened by a rotation after the one which started the mill.
+func (w *RotatingWriter) isLive(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file != nil && filepath.Clean(w.name) == path
+}
+
+// backups returns the rotated files in the directory of current, newest
+// first: the files named by backupName after the file, or after the pattern
+// with its conversions matching their digits, compressed or not.
+func (w *RotatingWriter) backups(current string) ([]os.FileInfo, error) {
+	dir := filepath.Dir(current)
+	infos, err := w.fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []os.FileInfo
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || name == filepath.Base(current) {
+			continue
+		}
+		if w.compressor != nil {
+			name = strings.TrimSuffix(name, w.compressor.Extension())
+		}
+		m := w.backupRe.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		if m[1] != "" {
+			if _, err := time.Parse(backupTimeFormat, m[1]); err != nil {
+				continue
+			}
+		}
+		backups = append(backups, info)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ModTime().After(backups[j].ModTime()) })
+	return backups, nil
+}
+
+// backupRegexp returns the regexp of the names of the rotated files: the
+// base name of filename with the timestamp of backupName before its
+// extension, or the base name of pattern with its conversions matching their
+// digits, and the timestamp of a rotation within the period.
+func backupRegexp(filename, pattern string) *regexp.Regexp {
+	if pattern != "" {
+		base := filepath.Base(pattern)
+		ext := filepath.Ext(base)
+		return regexp.MustCompile("^" + patternRegexp(base[:len(base)-len(ext)]) +
+			"(?:" + backupStamp + ")?" + patternRegexp(ext) + "$")
+	}
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	return regexp.MustCompile("^" + regexp.QuoteMeta(base[:len(base)-len(ext)]) +
+		backupStamp + regexp.QuoteMeta(ext) + "$")
+}
+
+// patternRegexp returns the regexp of the names expanded from the pattern by
+// formatFilename.
+func patternRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString("[0-9]{4}")
+		case 'm', 'd', 'H', 'M', 'S':
+			b.WriteString("[0-9]{2}")
+		case 'j':
+			b.WriteString("[0-9]{3}")
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i-1 : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// compress replaces the file by its compressed copy, keeping its
+// modification time so that the retention order is preserved.
+func (w *RotatingWriter) compress(path string, info os.FileInfo) error {
+	src, err := w.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dstPath := path + w.compressor.Extension()
+	dst, err := w.fs.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		cw, err := w.compressor.NewWriter(dst)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(cw, src); err != nil {
+			return err
+		}
+		return cw.Close()
+	}()
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		w.fs.Remove(dstPath)
+		return err
+	}
+	if w.uid >= 0 || w.gid >= 0 {
+		w.fs.Chown(dstPath, w.uid, w.gid)
+	}
+	w.fs.Chtimes(dstPath, info.ModTime(), info.ModTime())
+	return w.fs.Remove(path)
+}
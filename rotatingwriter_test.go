@@ -0,0 +1,446 @@
+package log4go
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memClock is a Clock moved forward by the tests.
+type memClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *memClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *memClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// memFS is an in memory FileSystem, stamping modifications with its clock.
+type memFS struct {
+	clock Clock
+
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	data     []byte
+	mode     os.FileMode
+	mtime    time.Time
+	link     string
+	uid, gid int
+	syncs    int
+}
+
+func newMemFS(clock Clock) *memFS {
+	return &memFS{clock: clock, nodes: make(map[string]*memNode)}
+}
+
+type memInfo struct {
+	name string
+	node memNode
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i *memInfo) Mode() os.FileMode  { return i.node.mode }
+func (i *memInfo) ModTime() time.Time { return i.node.mtime }
+func (i *memInfo) IsDir() bool        { return false }
+func (i *memInfo) Sys() interface{}   { return nil }
+
+type memFile struct {
+	fs   *memFS
+	name string
+	node *memNode
+	off  int
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.off >= len(f.node.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.off:])
+	f.off += n
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.node.data = append(f.node.data, p...)
+	f.node.mtime = f.fs.clock.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	f.node.syncs++
+	f.fs.mu.Unlock()
+	return nil
+}
+
+func (f *memFile) Close() error { return nil }
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return &memInfo{name: filepath.Base(f.name), node: *f.node}, nil
+}
+
+func (m *memFS) notExist(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, ok := m.nodes[name]
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, m.notExist("open", name)
+		}
+		node = &memNode{mode: perm, mtime: m.clock.Now(), uid: -1, gid: -1}
+		m.nodes[name] = node
+	}
+	if flag&os.O_TRUNC != 0 {
+		node.data = nil
+	}
+	return &memFile{fs: m, name: name, node: node}, nil
+}
+
+func (m *memFS) Lstat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, ok := m.nodes[name]
+	if !ok {
+		return nil, m.notExist("lstat", name)
+	}
+	return &memInfo{name: filepath.Base(name), node: *node}, nil
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	node, ok := m.nodes[name]
+	m.mu.Unlock()
+	if ok && node.link != "" {
+		return m.Stat(node.link)
+	}
+	return m.Lstat(name)
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, ok := m.nodes[oldpath]
+	if !ok {
+		return m.notExist("rename", oldpath)
+	}
+	delete(m.nodes, oldpath)
+	m.nodes[newpath] = node
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.nodes[name]; !ok {
+		return m.notExist("remove", name)
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+func (m *memFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var infos []os.FileInfo
+	for name, node := range m.nodes {
+		if filepath.Dir(name) == filepath.Clean(dir) {
+			infos = append(infos, &memInfo{name: filepath.Base(name), node: *node})
+		}
+	}
+	return infos, nil
+}
+
+func (m *memFS) MkdirAll(path string, perm os.FileMode) error {
+	return nil
+}
+
+func (m *memFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.nodes[newname]; ok {
+		return &os.PathError{Op: "symlink", Path: newname, Err: os.ErrExist}
+	}
+	m.nodes[newname] = &memNode{link: oldname, mode: os.ModeSymlink | 0777}
+	return nil
+}
+
+func (m *memFS) Chown(name string, uid, gid int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, ok := m.nodes[name]
+	if !ok {
+		return m.notExist("chown", name)
+	}
+	node.uid, node.gid = uid, gid
+	return nil
+}
+
+func (m *memFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, ok := m.nodes[name]
+	if !ok {
+		return m.notExist("chtimes", name)
+	}
+	node.mtime = mtime
+	return nil
+}
+
+// names returns the paths in the file system, sorted.
+func (m *memFS) names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.nodes))
+	for name := range m.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *memFS) node(name string) *memNode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.nodes[name]
+}
+
+func TestRotatingWriterSize(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	var events []RotateEvent
+	w := NewRotatingWriter(WithFileName("/logs/app.log"), WithMaxBackups(2), WithCompress(true),
+		WithFileOwner(1000, 1000), WithFileMode(0640), WithCurrentLink("/logs/current"),
+		WithRotateCallback(func(e RotateEvent) { events = append(events, e) }),
+		WithClock(clock), WithFileSystem(fs))
+	w.maxSize = 10
+
+	for i := 0; i < 4; i++ {
+		w.Write([]byte("line-" + string(rune('0'+i)) + "\n"))
+		clock.Advance(time.Second)
+	}
+	w.Close()
+
+	want := []string{
+		"/logs/app-2026-10-17T00-00-02.000.log.gz",
+		"/logs/app-2026-10-17T00-00-03.000.log.gz",
+		"/logs/app.log",
+		"/logs/current",
+	}
+	if got := fs.names(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("files %v, want %v", got, want)
+	}
+	if len(events) != 3 || events[2].Reason != RotateSize || events[2].Previous != "/logs/app-2026-10-17T00-00-03.000.log" {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if node := fs.node("/logs/app.log"); string(node.data) != "line-3\n" || node.mode != 0640 || node.uid != 1000 {
+		t.Errorf("unexpected current file %+v", node)
+	}
+	if link := fs.node("/logs/current").link; link != "/logs/app.log" {
+		t.Errorf("current links to %q", link)
+	}
+	zr, err := gzip.NewReader(strings.NewReader(string(fs.node(want[1]).data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != "line-2\n" {
+		t.Errorf("newest backup holds %q", data)
+	}
+}
+
+func TestRotatingWriterTime(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	w := NewRotatingWriter(WithFilenamePattern("/logs/app-%Y-%m-%d.log"), WithRotation(DailyRotation(0, 0)),
+		WithMaxAge(1), WithClock(clock), WithFileSystem(fs))
+
+	w.Write([]byte("first\n"))
+	clock.Advance(2 * time.Minute)
+	w.Write([]byte("second\n"))
+	w.millWG.Wait()
+	if got := fs.names(); len(got) != 2 || string(fs.node("/logs/app-2026-10-17.log").data) != "first\n" ||
+		string(fs.node("/logs/app-2026-10-18.log").data) != "second\n" {
+		t.Fatalf("unexpected files %v", got)
+	}
+
+	// both files are older than MaxAge by then
+	clock.Advance(3 * 24 * time.Hour)
+	w.Write([]byte("third\n"))
+	w.Close()
+	if got := fs.names(); len(got) != 1 || got[0] != "/logs/app-2026-10-21.log" {
+		t.Errorf("unexpected files %v", got)
+	}
+}
+
+func TestRotatingWriterRetention(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+
+	// a file left by a previous process in the previous period
+	f, _ := fs.OpenFile("/logs/app.log", os.O_CREATE|os.O_WRONLY, 0600)
+	f.Write([]byte("yesterday\n"))
+	clock.Advance(time.Hour)
+
+	w := NewRotatingWriter(WithFileName("/logs/app.log"), WithRotation(HourlyRotation()),
+		WithClock(clock), WithFileSystem(fs))
+	w.maxSize = 10
+	w.maxTotal = 20
+	for i := 0; i < 4; i++ {
+		w.Write([]byte("0123456789"))
+		clock.Advance(time.Second)
+	}
+	w.Close()
+
+	// the stale file and the first of the 10 bytes backups exceed 20 bytes
+	want := []string{
+		"/logs/app-2026-10-17T01-00-02.000.log",
+		"/logs/app-2026-10-17T01-00-03.000.log",
+		"/logs/app.log",
+	}
+	if got := fs.names(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files %v, want %v", got, want)
+	}
+}
+
+func TestRotatingWriterBackups(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	for _, name := range []string{
+		"app.log", "app-worker.log", "app-2026-10-16T00-00-00.000.log", "app-2026-10-16T00-00-00.000-1.log.gz",
+		"app-2026-13-16T00-00-00.000.log", "app-20261016.log", "app-20261016-2026-10-16T10-00-00.000.log",
+		"app-20261017.log", "app-worker-20261016.log",
+	} {
+		f, _ := fs.OpenFile("/logs/"+name, os.O_CREATE|os.O_WRONLY, 0600)
+		f.Close()
+	}
+
+	tests := []struct {
+		opt     OptionHandler
+		current string
+		want    []string
+	}{
+		{WithFileName("/logs/app.log"), "/logs/app.log",
+			[]string{"app-2026-10-16T00-00-00.000-1.log.gz", "app-2026-10-16T00-00-00.000.log"}},
+		{WithFilenamePattern("/logs/app-%Y%m%d.log"), "/logs/app-20261017.log",
+			[]string{"app-20261016-2026-10-16T10-00-00.000.log", "app-20261016.log"}},
+	}
+	for _, tt := range tests {
+		w := NewRotatingWriter(tt.opt, WithCompress(true), WithClock(clock), WithFileSystem(fs))
+		infos, err := w.backups(tt.current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, info := range infos {
+			got = append(got, info.Name())
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("backups of %s: %v, want %v", tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestFileLoggerRotate(t *testing.T) {
+	dir := t.TempDir()
+	flog := NewFileLogger(WithFileName(filepath.Join(dir, "app.log")), WithCompress(true),
+		WithCurrentLink(filepath.Join(dir, "current.log")))
+	flog.Info(context.TODO(), "before")
+	if err := flog.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	flog.Info(context.TODO(), "after")
+	if err := flog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	if len(backups) != 1 {
+		t.Fatalf("got backups %v", backups)
+	}
+	gz, _ := os.Open(backups[0])
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(zr); !strings.Contains(string(data), `"msg":"before"`) {
+		t.Errorf("backup holds %s", data)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "current.log")); err != nil || !strings.Contains(string(data), `"msg":"after"`) {
+		t.Errorf("current link reads %s, %v", data, err)
+	}
+}
+
+func TestRotatingWriterFsync(t *testing.T) {
+	clock := &memClock{t: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}
+	fs := newMemFS(clock)
+	w := NewRotatingWriter(WithFileName("/logs/app.log"), WithFsyncEvery(2, 0), WithClock(clock), WithFileSystem(fs))
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("entry\n"))
+	}
+	if syncs := fs.node("/logs/app.log").syncs; syncs != 1 {
+		t.Errorf("%d fsyncs after 3 entries", syncs)
+	}
+	// the third entry is committed on close
+	w.Close()
+	if syncs := fs.node("/logs/app.log").syncs; syncs != 2 {
+		t.Errorf("%d fsyncs after close", syncs)
+	}
+
+	w = NewRotatingWriter(WithFileName("/logs/interval.log"), WithFsyncEvery(0, 10*time.Millisecond),
+		WithClock(clock), WithFileSystem(fs))
+	w.Write([]byte("entry\n"))
+	time.Sleep(50 * time.Millisecond)
+	if syncs := fs.node("/logs/interval.log").syncs; syncs != 1 {
+		t.Errorf("%d fsyncs after the interval", syncs)
+	}
+	w.Close()
+
+	flog := NewFileLogger(WithFileName("/logs/level.log"), WithFsyncLevel(ErrorLevel),
+		WithClock(clock), WithFileSystem(fs))
+	flog.Info(context.TODO(), "info")
+	if syncs := fs.node("/logs/level.log").syncs; syncs != 0 {
+		t.Errorf("%d fsyncs after info", syncs)
+	}
+	flog.Error(context.TODO(), "error")
+	if syncs := fs.node("/logs/level.log").syncs; syncs != 1 {
+		t.Errorf("%d fsyncs after error", syncs)
+	}
+
+	w = NewRotatingWriter(WithFileName("/logs/never.log"), WithDisableFsync(true), WithClock(clock), WithFileSystem(fs))
+	w.Write([]byte("entry\n"))
+	w.Sync()
+	if syncs := fs.node("/logs/never.log").syncs; syncs != 0 {
+		t.Errorf("%d fsyncs with fsync disabled", syncs)
+	}
+}
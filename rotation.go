@@ -0,0 +1,202 @@
+package log4go
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RotationPolicy decides when FileLogger rotates the log file on time.
+type RotationPolicy interface {
+	// Next returns the first rotation time after t, in the location of t.
+	Next(t time.Time) time.Time
+}
+
+// RotationPolicyFunc adapts a function to a RotationPolicy.
+type RotationPolicyFunc func(t time.Time) time.Time
+
+func (f RotationPolicyFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// HourlyRotation rotates at the start of every hour.
+func HourlyRotation() RotationPolicy {
+	return RotationPolicyFunc(func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	})
+}
+
+// DailyRotation rotates every day at hour:minute.
+func DailyRotation(hour, minute int) RotationPolicy {
+	return RotationPolicyFunc(func(t time.Time) time.Time {
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, hour, minute, 0, 0, t.Location())
+		}
+		return next
+	})
+}
+
+// cronRotation matches times against the five fields of a cron
+// expression, each a bit set of the allowed values.
+type cronRotation struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record an unrestricted day field: when both day
+	// fields are restricted, a day matching either of them matches.
+	domStar, dowStar bool
+}
+
+// CronRotation rotates at the times matching the cron expression
+// "minute hour day-of-month month day-of-week". Each field is "*", a
+// value, a range "a-b", or a comma separated list of them, each optionally
+// followed by a step "/n". Months and days of week are numbers, Sunday is 0
+// or 7. The shortcuts @hourly, @daily, @midnight, @weekly and @monthly are
+// also accepted.
+func CronRotation(spec string) (RotationPolicy, error) {
+	switch strings.TrimSpace(spec) {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("log4go: cron expression %q must have 5 fields", spec)
+	}
+	var c cronRotation
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("log4go: cron expression %q: %v", spec, err)
+		}
+		*sets[i] = set
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField returns the bit set of the values allowed by the field.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				// "a/n" stands for "a-max/n"
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// Next finds the next matching minute, skipping the months, days and hours
+// that can't match. It returns the zero time if no time matches within five
+// years, as for "0 0 30 2 *".
+func (c *cronRotation) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cronRotation) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// formatFilename expands the strftime like conversions of the pattern with
+// t: %Y year, %m month, %d day, %H hour, %M minute, %S second, %j day of the
+// year, and %% a literal '%'.
+func formatFilename(pattern string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString(fmt.Sprintf("%04d", t.Year()))
+		case 'm':
+			b.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+		case 'd':
+			b.WriteString(fmt.Sprintf("%02d", t.Day()))
+		case 'H':
+			b.WriteString(fmt.Sprintf("%02d", t.Hour()))
+		case 'M':
+			b.WriteString(fmt.Sprintf("%02d", t.Minute()))
+		case 'S':
+			b.WriteString(fmt.Sprintf("%02d", t.Second()))
+		case 'j':
+			b.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
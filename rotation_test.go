@@ -0,0 +1,58 @@
+package log4go
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRotationPolicies(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	weekdays, err := CronRotation("30 6 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarter, err := CronRotation("*/15 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	monthly, err := CronRotation("@monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		policy   RotationPolicy
+		from, to string
+	}{
+		{HourlyRotation(), "2026-10-17 23:10", "2026-10-18 00:00"},
+		{DailyRotation(0, 0), "2026-10-17 00:00", "2026-10-18 00:00"},
+		{DailyRotation(6, 30), "2026-10-17 05:00", "2026-10-17 06:30"},
+		// 2026-10-17 is a Saturday
+		{weekdays, "2026-10-17 12:00", "2026-10-19 06:30"},
+		{quarter, "2026-10-17 12:15", "2026-10-17 12:30"},
+		{monthly, "2026-12-05 12:00", "2027-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Next(at(tt.from)); !got.Equal(at(tt.to)) {
+			t.Errorf("next after %s = %s, want %s", tt.from, got, tt.to)
+		}
+	}
+
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *"} {
+		if _, err := CronRotation(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestFormatFilename(t *testing.T) {
+	ts := time.Date(2026, 10, 7, 9, 5, 0, 0, time.UTC)
+	if got := formatFilename("app-%Y-%m-%d_%H%M-%j-100%%.log", ts); got != "app-2026-10-07_0905-280-100%.log" {
+		t.Errorf("unexpected file name %q", got)
+	}
+}
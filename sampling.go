@@ -0,0 +1,58 @@
+package log4go
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry is a log entry, as seen by the sampling hook.
+type Entry = zapcore.Entry
+
+// SamplingDecision is the decision of the sampler on an entry.
+type SamplingDecision = zapcore.SamplingDecision
+
+const (
+	// LogDropped indicates that the sampler dropped the entry.
+	LogDropped = zapcore.LogDropped
+	// LogSampled indicates that the sampler let the entry through.
+	LogSampled = zapcore.LogSampled
+)
+
+// newSamplingCore samples the entries written to core as configured by the
+// options, unless their level is exempt.
+func newSamplingCore(core zapcore.Core, opts Options) zapcore.Core {
+	if opts.SampleInitial <= 0 || opts.SampleInterval <= 0 {
+		return core
+	}
+	var sopts []zapcore.SamplerOption
+	if opts.SampleHook != nil {
+		sopts = append(sopts, zapcore.SamplerHook(opts.SampleHook))
+	}
+	sampled := zapcore.NewSamplerWithOptions(core, opts.SampleInterval, opts.SampleInitial, opts.SampleThereafter, sopts...)
+	if len(opts.SampleExemptLevels) == 0 {
+		return sampled
+	}
+	exempt := make(map[Level]bool, len(opts.SampleExemptLevels))
+	for _, lvl := range opts.SampleExemptLevels {
+		exempt[lvl] = true
+	}
+	return &exemptCore{Core: core, sampled: sampled, exempt: exempt}
+}
+
+// exemptCore writes the entries of the exempt levels to the embedded core
+// directly, and the others through the sampled one.
+type exemptCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+	exempt  map[Level]bool
+}
+
+func (c *exemptCore) With(fields []zapcore.Field) zapcore.Core {
+	return &exemptCore{Core: c.Core.With(fields), sampled: c.sampled.With(fields), exempt: c.exempt}
+}
+
+func (c *exemptCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.exempt[ent.Level] {
+		return c.Core.Check(ent, ce)
+	}
+	return c.sampled.Check(ent, ce)
+}
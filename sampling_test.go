@@ -0,0 +1,43 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	var sampled, dropped int
+	clog := NewConsoleLogger(WithOutput(&buf), WithStack(false), WithSampling(time.Hour, 2, 3),
+		WithSampleExempt(ErrorLevel), WithSampleHook(func(ent Entry, dec SamplingDecision) {
+			if dec&LogDropped != 0 {
+				dropped++
+			} else {
+				sampled++
+			}
+		}))
+	glog := NewGroupLogger(clog)
+	for i := 0; i < 10; i++ {
+		glog.Info(context.TODO(), "retrying")
+		glog.Error(context.TODO(), "failed")
+	}
+	glog.Info(context.TODO(), "other")
+
+	out := buf.String()
+	// the 1st, 2nd, 5th and 8th entries of the message are logged
+	if n := strings.Count(out, "retrying"); n != 4 {
+		t.Errorf("%d entries sampled, want 4", n)
+	}
+	if n := strings.Count(out, "failed"); n != 10 {
+		t.Errorf("%d exempt entries logged, want 10", n)
+	}
+	if !strings.Contains(out, "other") {
+		t.Error("other message sampled out")
+	}
+	if sampled != 5 || dropped != 6 {
+		t.Errorf("hook saw %d sampled and %d dropped", sampled, dropped)
+	}
+}
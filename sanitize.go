@@ -0,0 +1,89 @@
+package log4go
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ansiSequence matches the ANSI escape sequences: CSI sequences such as
+// colors and cursor moves, OSC sequences such as window titles and links,
+// and the other two byte sequences.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]|\x9b[0-?]*[ -/]*[@-~]`)
+
+// sanitizeTransform sanitizes the message, the keys and the string values
+// of the fields. Objects, arrays and reflected values are left to the
+// encoders, which escape them as JSON.
+func sanitizeTransform(ent *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	ent.Message = sanitizeString(ent.Message)
+	ent.LoggerName = sanitizeString(ent.LoggerName)
+	for i := range fields {
+		f := &fields[i]
+		f.Key = sanitizeString(f.Key)
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = sanitizeString(f.String)
+		case zapcore.ByteStringType, zapcore.ErrorType, zapcore.StringerType:
+			if s, ok := fieldValue(*f).(string); ok {
+				if clean := sanitizeString(s); clean != s {
+					*f = zap.String(f.Key, clean)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// sanitizeString strips the ANSI escape sequences of s, and escapes its
+// control characters and invalid UTF-8, so that it holds on a single line
+// of plain text, e.g. "a\nb" is written as `a\nb`.
+func sanitizeString(s string) string {
+	if isPlainText(s) {
+		return s
+	}
+	if strings.ContainsAny(s, "\x1b\u009b") {
+		s = ansiSequence.ReplaceAllString(s, "")
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteString("\ufffd")
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			b.WriteString(`\x`)
+			if r < 0x10 {
+				b.WriteByte('0')
+			}
+			b.WriteString(strconv.FormatInt(int64(r), 16))
+		case r >= 0x80 && r < 0xa0, r == '\u2028', r == '\u2029':
+			b.WriteString(`\u`)
+			b.WriteString(strconv.FormatInt(int64(r)|0x10000, 16)[1:])
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// isPlainText reports whether s is printable ASCII.
+func isPlainText(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSanitizeString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain text", "plain text"},
+		{"forged\n2026-10-18 INFO admin logged in", `forged\n2026-10-18 INFO admin logged in`},
+		{"\x1b[31mred\x1b[0m \x1b]0;title\x07done", "red done"},
+		{"tab\tcr\r\x00\x7f", `tab\tcr\r\x00\x7f`},
+		{"bad \xff utf8 \u00e9", "bad \ufffd utf8 \u00e9"},
+		{"line\u2028sep", `line\u2028sep`},
+	}
+	for _, tt := range tests {
+		if got := sanitizeString(tt.in); got != tt.want {
+			t.Errorf("sanitizeString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeLoggers(t *testing.T) {
+	var console, js bytes.Buffer
+	clog := NewWriterLogger(&console, WithEncoding(ConsoleEncoding), WithSanitize(true), WithStack(false))
+	clog.Info(context.TODO(), "user\nINFO forged", String("name", "\x1b[2Jbob\r\n"), Field(zap.NamedError("err", errors.New("bad\ninput"))))
+	if out := console.String(); strings.Count(out, "\n") != 1 || strings.Contains(out, "\x1b") || !strings.Contains(out, `user\nINFO forged`) {
+		t.Errorf("unexpected console output %q", out)
+	}
+
+	jlog := NewWriterLogger(&js, WithSanitize(true), WithStack(false))
+	jlog.Info(context.TODO(), "\x1b[31malert\x1b[0m", String("k\n", "v\xff"))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(js.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "alert" || entry[`k\n`] != "v\ufffd" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	// off by default
+	console.Reset()
+	NewWriterLogger(&console, WithEncoding(ConsoleEncoding), WithStack(false)).Info(context.TODO(), "a\nb")
+	if !strings.Contains(console.String(), "a\nb") {
+		t.Errorf("sanitized without the option: %q", console.String())
+	}
+}
@@ -0,0 +1,81 @@
+package log4go
+
+import (
+	"encoding/binary"
+)
+
+const (
+	snappyTableBits = 14
+	snappyMaxOffset = 1<<16 - 1
+)
+
+// snappyEncode returns src compressed in the snappy block format, as
+// expected by the Loki push API. Matches are found through a hash table of
+// the last position of each 4 byte sequence, and limited to 64 KiB back so
+// that they always fit in a 2 byte offset.
+func snappyEncode(src []byte) []byte {
+	var varint [binary.MaxVarintLen64]byte
+	dst := make([]byte, 0, len(src)/2+16)
+	dst = append(dst, varint[:binary.PutUvarint(varint[:], uint64(len(src)))]...)
+
+	var table [1 << snappyTableBits]int32
+	lit := 0
+	for i := 0; i+4 <= len(src); {
+		cur := binary.LittleEndian.Uint32(src[i:])
+		h := (cur * 0x1e35a7bd) >> (32 - snappyTableBits)
+		cand := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if cand < 0 || i-cand > snappyMaxOffset || binary.LittleEndian.Uint32(src[cand:]) != cur {
+			i++
+			continue
+		}
+		n := 4
+		for i+n < len(src) && src[cand+n] == src[i+n] {
+			n++
+		}
+		dst = snappyLiteral(dst, src[lit:i])
+		dst = snappyCopy(dst, i-cand, n)
+		i += n
+		lit = i
+	}
+	return snappyLiteral(dst, src[lit:])
+}
+
+// snappyLiteral appends a literal element holding lit.
+func snappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := len(lit) - 1
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+// snappyCopy appends the copy elements repeating the n bytes found offset
+// bytes back. n is at least 4.
+func snappyCopy(dst []byte, offset, n int) []byte {
+	for n >= 68 {
+		dst = append(dst, 2|63<<2, byte(offset), byte(offset>>8))
+		n -= 64
+	}
+	if n > 64 {
+		// keep at least 4 bytes for the last element
+		dst = append(dst, 2|59<<2, byte(offset), byte(offset>>8))
+		n -= 60
+	}
+	if n >= 12 || offset >= 2048 {
+		return append(dst, 2|byte(n-1)<<2, byte(offset), byte(offset>>8))
+	}
+	return append(dst, 1|byte(n-4)<<2|byte(offset>>8)<<5, byte(offset))
+}
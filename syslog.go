@@ -0,0 +1,416 @@
+package log4go
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogEncoding writes each entry as a syslog message, framed by
+// SyslogFormat.
+const SyslogEncoding = "syslog"
+
+// SyslogFacility is the syslog facility of the messages.
+type SyslogFacility int
+
+const (
+	FacilityKern     SyslogFacility = 0
+	FacilityUser     SyslogFacility = 1
+	FacilityMail     SyslogFacility = 2
+	FacilityDaemon   SyslogFacility = 3
+	FacilityAuth     SyslogFacility = 4
+	FacilitySyslog   SyslogFacility = 5
+	FacilityLPR      SyslogFacility = 6
+	FacilityNews     SyslogFacility = 7
+	FacilityUUCP     SyslogFacility = 8
+	FacilityCron     SyslogFacility = 9
+	FacilityAuthPriv SyslogFacility = 10
+	FacilityFTP      SyslogFacility = 11
+	FacilityLocal0   SyslogFacility = 16
+	FacilityLocal1   SyslogFacility = 17
+	FacilityLocal2   SyslogFacility = 18
+	FacilityLocal3   SyslogFacility = 19
+	FacilityLocal4   SyslogFacility = 20
+	FacilityLocal5   SyslogFacility = 21
+	FacilityLocal6   SyslogFacility = 22
+	FacilityLocal7   SyslogFacility = 23
+)
+
+// SyslogFormat is the layout of the syslog messages.
+type SyslogFormat int
+
+const (
+	// RFC5424 messages carry the fields as structured data.
+	RFC5424 SyslogFormat = iota
+	// RFC3164 messages, the BSD syslog format, carry the fields appended to
+	// the message in logfmt.
+	RFC3164
+)
+
+// DefaultSyslogSDID is the SD-ID of the structured data element carrying the
+// fields in RFC 5424 messages. 32473 is the enterprise number reserved for
+// documentation, set SyslogSDID to an ID under your own enterprise number.
+const DefaultSyslogSDID = "log4go@32473"
+
+// syslogLocalPaths are the local sockets tried when no address is given.
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var _syslogPool = buffer.NewPool()
+
+// NewSyslogEncoder creates an encoder which writes each entry as a syslog
+// message in the format, facility, app name, process id and structured
+// data ID configured by the options. Messages aren't framed, framing
+// depends on the transport.
+func NewSyslogEncoder(cfg EncoderConfig, opts Options) Encoder {
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	}
+	host := opts.Hostname
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	app := opts.AppName
+	if app == "" {
+		app = filepath.Base(os.Args[0])
+	}
+	procID := opts.ProcID
+	if procID == "" {
+		procID = strconv.Itoa(os.Getpid())
+	}
+	sdID := opts.SyslogSDID
+	if sdID == "" {
+		sdID = DefaultSyslogSDID
+	}
+	return &syslogEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              &cfg,
+		format:           opts.SyslogFormat,
+		facility:         opts.SyslogFacility,
+		host:             syslogHeaderField(host, 255),
+		app:              syslogHeaderField(app, 48),
+		procID:           syslogHeaderField(procID, 128),
+		sdID:             syslogParamName(sdID),
+	}
+}
+
+type syslogEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg      *EncoderConfig
+	format   SyslogFormat
+	facility SyslogFacility
+	host     string
+	app      string
+	procID   string
+	sdID     string
+}
+
+func (s *syslogEncoder) Clone() zapcore.Encoder {
+	clone := *s
+	clone.MapObjectEncoder = zapcore.NewMapObjectEncoder()
+	for k, v := range s.Fields {
+		clone.Fields[k] = v
+	}
+	return &clone
+}
+
+func (s *syslogEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	enc := s.Clone().(*syslogEncoder)
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	if ent.Caller.Defined && s.cfg.CallerKey != "" {
+		enc.Fields[s.cfg.CallerKey] = ent.Caller.String()
+	}
+
+	// fields flattened in key order
+	var params [][2]string
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		flattenField(k, enc.Fields[k], func(key string, val interface{}) {
+			params = append(params, [2]string{key, fmt.Sprint(flatValue(s.cfg, val))})
+		})
+	}
+
+	msg := ent.Message
+	if ent.Stack != "" && s.cfg.StacktraceKey != "" {
+		msg += "\n" + ent.Stack
+	}
+
+	line := _syslogPool.Get()
+	line.AppendByte('<')
+	line.AppendInt(int64(int(s.facility)*8 + syslogSeverity(ent.Level)))
+	line.AppendByte('>')
+	if s.format == RFC3164 {
+		s.encode3164(line, ent, msg, params)
+	} else {
+		s.encode5424(line, ent, msg, params)
+	}
+	line.AppendString(s.cfg.LineEnding)
+	return line, nil
+}
+
+func (s *syslogEncoder) encode5424(line *buffer.Buffer, ent zapcore.Entry, msg string, params [][2]string) {
+	line.AppendString("1 ")
+	line.AppendString(ent.Time.Format("2006-01-02T15:04:05.000000Z07:00"))
+	for _, f := range []string{s.host, s.app, s.procID, syslogHeaderField(ent.LoggerName, 32)} {
+		line.AppendByte(' ')
+		line.AppendString(f)
+	}
+	line.AppendByte(' ')
+	if len(params) == 0 {
+		line.AppendByte('-')
+	} else {
+		line.AppendByte('[')
+		line.AppendString(s.sdID)
+		for _, p := range params {
+			line.AppendByte(' ')
+			line.AppendString(syslogParamName(p[0]))
+			line.AppendString(`="`)
+			writeSyslogParamValue(line, p[1])
+			line.AppendByte('"')
+		}
+		line.AppendByte(']')
+	}
+	if msg != "" {
+		line.AppendByte(' ')
+		line.AppendString(msg)
+	}
+}
+
+func (s *syslogEncoder) encode3164(line *buffer.Buffer, ent zapcore.Entry, msg string, params [][2]string) {
+	line.AppendString(ent.Time.Format(time.Stamp))
+	line.AppendByte(' ')
+	line.AppendString(s.host)
+	line.AppendByte(' ')
+	line.AppendString(s.app)
+	line.AppendByte('[')
+	line.AppendString(s.procID)
+	line.AppendString("]: ")
+	line.AppendString(msg)
+	for _, p := range params {
+		line.AppendByte(' ')
+		writeLogfmtKey(line, p[0])
+		line.AppendByte('=')
+		writeLogfmtValue(line, p[1])
+	}
+}
+
+// syslogHeaderField returns s restricted to the printable US-ASCII
+// characters and max bytes, or the nil value "-" if empty.
+func syslogHeaderField(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// syslogParamName returns name without the characters not allowed in an
+// SD-NAME, truncated to 32 bytes.
+func syslogParamName(name string) string {
+	b := make([]byte, 0, len(name))
+	for i := 0; i < len(name) && len(b) < 32; i++ {
+		c := name[i]
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// writeSyslogParamValue writes the value escaping '"', '\' and ']'.
+func writeSyslogParamValue(line *buffer.Buffer, val string) {
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; c {
+		case '"', '\\', ']':
+			line.AppendByte('\\')
+			line.AppendByte(c)
+		default:
+			line.AppendByte(c)
+		}
+	}
+}
+
+// syslogWriter sends each message to the syslog server, framed by octet
+// counting over stream transports. After a failed write the connection is
+// established again and the message sent once more.
+type syslogWriter struct {
+	network string
+	address string
+	options Options
+
+	mu       sync.Mutex
+	conn     net.Conn
+	failures int
+	nextDial time.Time
+	dialErr  error
+}
+
+// connect establishes the connection unless it is up, or returns the last
+// dial error while the backoff delay isn't over.
+func (s *syslogWriter) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+	if time.Now().Before(s.nextDial) {
+		return s.dialErr
+	}
+	conn, err := s.dial()
+	if err != nil {
+		s.nextDial = time.Now().Add(backoffDelay(s.failures, s.options.RetryBackoff, s.options.RetryMaxBackoff))
+		s.failures++
+		s.dialErr = err
+		return err
+	}
+	s.conn = conn
+	s.failures = 0
+	return nil
+}
+
+func (s *syslogWriter) dial() (net.Conn, error) {
+	if s.address != "" {
+		return dialNetwork(s.network, s.address, s.options.DialTimeout, s.options.TLSConfig)
+	}
+	// local syslog daemon
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range syslogLocalPaths {
+			conn, err := net.DialTimeout(network, path, s.options.DialTimeout)
+			if err == nil {
+				s.network = network
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("log4go: unix syslog delivery error")
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a failed send is retried once on a new connection, as the server may
+	// have closed an idle one
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = s.connect(); err != nil {
+			return 0, err
+		}
+		if err = s.send(p); err == nil {
+			return len(p), nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return 0, err
+}
+
+// syslogLineEscaper escapes the line breaks of the messages framed by a
+// newline.
+var syslogLineEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+func (s *syslogWriter) send(p []byte) error {
+	if !isStreamNetwork(s.network) {
+		_, err := s.conn.Write(p)
+		return err
+	}
+	buf := _syslogPool.Get()
+	defer buf.Free()
+	if strings.HasPrefix(s.network, "tcp") || s.network == "tls" {
+		// octet counting framing of RFC 6587
+		buf.AppendInt(int64(len(p)))
+		buf.AppendByte(' ')
+		buf.Write(p)
+	} else {
+		// local daemons read the unix stream socket by lines, so the line
+		// breaks of the message, e.g. of a stack trace, are escaped
+		buf.AppendString(syslogLineEscaper.Replace(string(p)))
+		buf.AppendByte('\n')
+	}
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+func (s *syslogWriter) Sync() error {
+	return nil
+}
+
+// Close closes the connection.
+func (s *syslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// SyslogLogger logger sends entries to a syslog server
+type SyslogLogger struct {
+	zapLogger
+	writer *syslogWriter
+}
+
+// NewSyslogLogger create a new SyslogLogger which sends the entries to the
+// address on the network: "udp", "tcp", "tls" or "unix". With an empty
+// network and address, entries go to the local syslog daemon through
+// /dev/log. Over TCP and TLS, messages are framed by octet counting, over a
+// unix stream socket they end with a newline, their line breaks escaped as
+// \n and \r.
+//
+// The connection is established on the first entry, and established again
+// when a write fails. A failed dial is retried after RetryBackoff, doubled
+// after each failure up to RetryMaxBackoff.
+func NewSyslogLogger(network, address string, oh ...OptionHandler) *SyslogLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+	opts.Encoding = SyslogEncoding
+	opts.SkipLineEnding = true
+
+	w := &syslogWriter{
+		network: network,
+		address: address,
+		options: opts,
+	}
+	return &SyslogLogger{
+		zapLogger: newZapLogger(opts, w, SyslogEncoding),
+		writer:    w,
+	}
+}
+
+// Close flushes any buffered log entries, and closes the connection.
+func (s *SyslogLogger) Close() error {
+	s.zap.Sync()
+	return s.writer.Close()
+}
@@ -0,0 +1,183 @@
+package log4go
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogLoggerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	slog := NewSyslogLogger("udp", conn.LocalAddr().String(),
+		WithHostname("h1"), WithAppName("app"), WithProcID("42"), WithName("http"),
+		WithSyslogFacility(FacilityLocal0), WithCaller(false), WithStack(false))
+	defer slog.Close()
+	slog.Error(context.TODO(), "failed", String("path", `/a"b]`), Int("status", 500))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0 * 8 + error severity 3
+	re := regexp.MustCompile(`^<131>1 \S+ h1 app 42 http \[log4go@32473 path="/a\\"b\\]" status="500"\] failed$`)
+	if !re.Match(buf[:n]) {
+		t.Errorf("unexpected rfc5424 message %q", buf[:n])
+	}
+}
+
+func TestSyslogLoggerTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	slog := NewSyslogLogger("tcp", ln.Addr().String(),
+		WithSyslogFormat(RFC3164), WithHostname("h1"), WithAppName("app"), WithProcID("42"),
+		WithCaller(false), WithStack(false))
+	defer slog.Close()
+
+	ctx := context.TODO()
+	read := func(conn net.Conn, r *bufio.Reader) string {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			t.Fatalf("bad octet count %q", size)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		return string(msg)
+	}
+
+	slog.Info(ctx, "first", String("k", "a b"))
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := read(conn, bufio.NewReader(conn))
+	if !regexp.MustCompile(`^<14>\w{3} [ \d]\d \d\d:\d\d:\d\d h1 app\[42\]: first k="a b"$`).MatchString(msg) {
+		t.Errorf("unexpected rfc3164 message %q", msg)
+	}
+
+	// the server drops the connection, the logger connects again
+	conn.Close()
+	slog.Info(ctx, "lost")
+	time.Sleep(50 * time.Millisecond)
+	slog.Info(ctx, "second")
+	conn, err = ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		msg = read(conn, r)
+		if strings.HasSuffix(msg, "second") {
+			break
+		}
+	}
+}
+
+func TestSyslogLoggerUnixStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unsupported: %v", err)
+	}
+	defer ln.Close()
+
+	slog := NewSyslogLogger("unix", path, WithHostname("h1"), WithAppName("app"), WithCaller(false), WithStack(false))
+	defer slog.Close()
+	slog.Info(context.TODO(), "first")
+	slog.Info(context.TODO(), "second")
+	slog.Error(context.TODO(), "multi\r\nline")
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"first", "second"} {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(line, "<14>1 ") || !strings.HasSuffix(line, " "+want+"\n") {
+			t.Errorf("unexpected unix stream message %q", line)
+		}
+	}
+	// the line breaks of the messages and stack traces don't split them
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "<11>1 ") || !strings.HasSuffix(line, ` multi\r\nline`+"\n") {
+		t.Errorf("unexpected unix stream message %q", line)
+	}
+
+	stlog := NewSyslogLogger("unix", path, WithHostname("h1"), WithAppName("app"), WithStack(true))
+	defer stlog.Close()
+	stlog.Error(context.TODO(), "with stack")
+	stlog.Info(context.TODO(), "next")
+	sconn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sconn.Close()
+	sconn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r = bufio.NewReader(sconn)
+	line, err = r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, "with stack") || !strings.Contains(line, "syslog_test.go") ||
+		!strings.Contains(line, `\n`) {
+		t.Errorf("unexpected message with a stack trace %q", line)
+	}
+	line, err = r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "<14>1 ") || !strings.Contains(line, "next") {
+		t.Errorf("unexpected message after the stack trace %q: %v", line, err)
+	}
+}
+
+func TestSyslogWriterDialBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	opts := DefaultOption()
+	opts.DialTimeout = time.Second
+	opts.RetryBackoff, opts.RetryMaxBackoff = time.Minute, time.Minute
+	w := &syslogWriter{network: "tcp", address: addr, options: opts}
+	if _, err := w.Write([]byte("<14>1 - - - - - - m")); err == nil {
+		t.Fatal("expected a dial error")
+	}
+	if _, err := w.Write([]byte("<14>1 - - - - - - m")); err == nil || w.failures != 1 {
+		t.Errorf("expected the dial to wait for the backoff, got %d failures, error %v", w.failures, err)
+	}
+}
@@ -0,0 +1,99 @@
+package log4go
+
+import (
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// transformErrorOutput reports the errors of the wrapped cores, as zap does
+// by default.
+var transformErrorOutput = zapcore.Lock(os.Stderr)
+
+// fieldTransform rewrites an entry and its fields, including the fields
+// added by With, before they are encoded, returning the fields, either
+// fields modified in place or a new slice.
+type fieldTransform func(ent *zapcore.Entry, fields []zapcore.Field) []zapcore.Field
+
+// fieldTransforms returns the transforms configured by the options, in the
+// order they apply. The entries truncated by the size limits are counted in
+// truncations.
+func fieldTransforms(opts Options, truncations *uint64) []fieldTransform {
+	var transforms []fieldTransform
+	if len(opts.RedactRules) > 0 {
+		transforms = append(transforms, newRedactor(opts).transform)
+	}
+	if opts.Sanitize {
+		transforms = append(transforms, sanitizeTransform)
+	}
+	if opts.MaxMessageLength > 0 || opts.MaxFieldLength > 0 || opts.MaxFields > 0 || opts.MaxEntrySize > 0 {
+		transforms = append(transforms, newLimiter(opts, truncations).transform)
+	}
+	return transforms
+}
+
+// transformCore applies transforms to the entries written to the wrapped
+// core, whatever the sink. The fields added by With are kept until the
+// entries are written, so that the transforms see all the fields of an
+// entry.
+type transformCore struct {
+	zapcore.Core
+	transforms []fieldTransform
+	fields     []zapcore.Field
+}
+
+func newTransformCore(core zapcore.Core, transforms []fieldTransform) zapcore.Core {
+	if len(transforms) == 0 {
+		return core
+	}
+	return &transformCore{Core: core, transforms: transforms}
+}
+
+// withFields returns the fields added by With followed by fields, in a new
+// slice so that the fields of the caller are left untouched.
+func (c *transformCore) withFields(fields []zapcore.Field) []zapcore.Field {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	return append(append(all, c.fields...), fields...)
+}
+
+func (c *transformCore) apply(ent *zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	fields = c.withFields(fields)
+	for _, fn := range c.transforms {
+		fields = fn(ent, fields)
+	}
+	return fields
+}
+
+func (c *transformCore) With(fields []zapcore.Field) zapcore.Core {
+	return &transformCore{Core: c.Core, transforms: c.transforms, fields: c.withFields(fields)}
+}
+
+// Check asks the wrapped core, so that a Tee writes the entry only to the
+// cores enabling its level, and transforms the entry before they write it.
+func (c *transformCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, nil)
+	if checked == nil {
+		return ce
+	}
+	checked.ErrorOutput = transformErrorOutput
+	return ce.AddCore(ent, &checkedTransform{transformCore: c, checked: checked})
+}
+
+func (c *transformCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = c.apply(&ent, fields)
+	return c.Core.Write(ent, fields)
+}
+
+// checkedTransform writes the transformed entry to the cores which checked
+// it.
+type checkedTransform struct {
+	*transformCore
+	checked *zapcore.CheckedEntry
+}
+
+func (c *checkedTransform) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = c.apply(&ent, fields)
+	c.checked.Entry = ent
+	c.checked.Write(fields...)
+	return nil
+}
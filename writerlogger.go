@@ -0,0 +1,28 @@
+package log4go
+
+import (
+	"io"
+)
+
+// WriterLogger logger writes to an arbitrary io.Writer, such as os.Stderr,
+// a bytes.Buffer in tests, a pipe or a network connection.
+type WriterLogger struct {
+	zapLogger
+}
+
+// NewWriterLogger create a new WriterLogger which writes json encoded entries
+// into w. If w implements WriteSyncer, its Sync method is called when the
+// logger is synced. Writes are serialized, so w needn't be safe for
+// concurrent use.
+func NewWriterLogger(w io.Writer, oh ...OptionHandler) *WriterLogger {
+
+	// initialize config
+	opts := DefaultOption()
+	for _, fn := range oh {
+		fn(&opts)
+	}
+
+	return &WriterLogger{
+		zapLogger: newZapLogger(opts, w, JSONEncoding),
+	}
+}
@@ -0,0 +1,55 @@
+package log4go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriterLogger(t *testing.T) {
+	ctx := context.WithValue(context.TODO(), ContextFieldsKey, []Field{
+		String("s0", "context field"),
+	})
+
+	buf := &bytes.Buffer{}
+	wlog := NewWriterLogger(buf, WithStack(false), WithExtendFields(String("s1", "ext field1")))
+	wlog.Info(ctx, "writer test", Int("t1", 1))
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "writer test" || line["level"] != "info" {
+		t.Errorf("unexpected entry %v", line)
+	}
+	if line["t1"] != float64(1) || line["s0"] != "context field" || line["s1"] != "ext field1" {
+		t.Errorf("missing fields in %v", line)
+	}
+	if caller, _ := line["caller"].(string); !strings.Contains(caller, "writerlogger_test.go") {
+		t.Errorf("caller should be the log site, got %q", caller)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	ctx := context.TODO()
+
+	buf := &bytes.Buffer{}
+	NewConsoleLogger(WithOutput(buf), WithStack(false), WithCaller(false)).Info(ctx, "console", Int("t1", 1))
+	if !strings.Contains(buf.String(), "\tinfo\tconsole\t{\"t1\": 1}") {
+		t.Errorf("unexpected console output %q", buf.String())
+	}
+
+	buf.Reset()
+	NewConsoleLogger(WithOutput(buf), WithEncoding(JSONEncoding)).Info(ctx, "json")
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("expect json output, got %q", buf.String())
+	}
+
+	if err := RegisterEncoder(JSONEncoding, func(cfg EncoderConfig, opts Options) (Encoder, error) {
+		return nil, nil
+	}); err == nil {
+		t.Error("register an existing encoder name should fail")
+	}
+}
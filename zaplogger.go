@@ -0,0 +1,205 @@
+package log4go
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WriteSyncer is an io.Writer that can also flush any buffered data.
+type WriteSyncer = zapcore.WriteSyncer
+
+// AddSync converts an io.Writer to a WriteSyncer. It attempts to be
+// intelligent: if the concrete type of the io.Writer implements WriteSyncer,
+// we'll use the existing Sync method. If it doesn't, we'll add a no-op Sync.
+func AddSync(w io.Writer) WriteSyncer {
+	return zapcore.AddSync(w)
+}
+
+// zapLogger is the zap based implementation of Logger shared by the
+// concrete loggers.
+type zapLogger struct {
+	zap         *zap.Logger
+	extfields   []Field
+	truncations *uint64
+}
+
+// newEncoderConfig build the zap encoder config from the options
+func newEncoderConfig(opts Options) zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        opts.TimeKey,
+		LevelKey:       opts.LevelKey,
+		NameKey:        opts.NameKey,
+		CallerKey:      opts.CallerKey,
+		MessageKey:     opts.MessageKey,
+		StacktraceKey:  opts.StacktraceKey,
+		SkipLineEnding: opts.SkipLineEnding,
+		LineEnding:     opts.LineEnding,
+		FunctionKey:    opts.FunctionKey,
+		EncodeLevel: func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			opts.EncodeLevel(l, enc)
+		},
+		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			opts.EncodeTime(t, enc)
+		},
+		EncodeDuration: func(td time.Duration, enc zapcore.PrimitiveArrayEncoder) {
+			opts.EncodeDuration(td, enc)
+		},
+		EncodeCaller: func(ec zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+			opts.EncodeCaller(EntryCaller(ec), enc)
+		},
+		EncodeName: func(name string, enc zapcore.PrimitiveArrayEncoder) {
+			opts.EncodeName(name, enc)
+		},
+		NewReflectedEncoder: func(w io.Writer) zapcore.ReflectedEncoder {
+			return opts.NewReflectedEncoder(w)
+		},
+		ConsoleSeparator: opts.ConsoleSeparator,
+	}
+}
+
+// newZapLogger build a logger which writes the entries into out, encoded by
+// the encoder selected in the options, or fallback if none selected. Writes
+// are serialized, so out needn't be safe for concurrent use.
+func newZapLogger(opts Options, out io.Writer, fallback string) zapLogger {
+	return newZapLoggerWithCore(opts, newZapCore(opts, out, fallback, nil))
+}
+
+// newZapCore build the core writing the entries enabled by the options
+// level, and by enab if not nil, into out.
+func newZapCore(opts Options, out io.Writer, fallback string, enab zapcore.LevelEnabler) zapcore.Core {
+	opts.Color = colorEnabled(opts, out)
+	ws := zapcore.Lock(AddSync(out))
+	enc := newEncoder(newEncoderConfig(opts), opts, fallback)
+	// log level
+	atomicLevel := zap.NewAtomicLevel()
+	atomicLevel.SetLevel(opts.Level)
+	if enab == nil {
+		return zapcore.NewCore(enc, ws, atomicLevel)
+	}
+	return zapcore.NewCore(enc, ws, zap.LevelEnablerFunc(func(l Level) bool {
+		return atomicLevel.Enabled(l) && enab.Enabled(l)
+	}))
+}
+
+// newZapLoggerWithCore build a logger on top of the given core, for sinks
+// which need more control than an encoder and a WriteSyncer. The entries
+// are sampled as configured by the options, then the field transforms, such
+// as redaction, apply to every entry.
+func newZapLoggerWithCore(opts Options, core zapcore.Core) zapLogger {
+	truncations := new(uint64)
+	core = newTransformCore(core, fieldTransforms(opts, truncations))
+	core = newSamplingCore(core, opts)
+	zapOpts := make([]zap.Option, 0)
+	if opts.WithCaller {
+		zapOpts = append(zapOpts, zap.AddCaller(), zap.AddCallerSkip(2))
+	}
+	if opts.WithStack {
+		zapOpts = append(zapOpts, zap.AddStacktrace(DebugLevel))
+	}
+	logger := zap.New(core, zapOpts...)
+	if opts.Name != "" {
+		logger = logger.Named(opts.Name)
+	}
+	return zapLogger{
+		zap:         logger,
+		extfields:   opts.ExtFields,
+		truncations: truncations,
+	}
+}
+
+// Truncations returns the number of entries truncated by the size limits
+// of the options.
+func (z *zapLogger) Truncations() uint64 {
+	if z.truncations == nil {
+		return 0
+	}
+	return atomic.LoadUint64(z.truncations)
+}
+
+// Info logs a message at InfoLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+func (z *zapLogger) Info(ctx context.Context, msg string, fields ...Field) {
+	z.log(ctx, InfoLevel, msg, fields)
+}
+
+// Debug logs a message at DebugLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+func (z *zapLogger) Debug(ctx context.Context, msg string, fields ...Field) {
+	z.log(ctx, DebugLevel, msg, fields)
+}
+
+// Warn logs a message at WarnLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+func (z *zapLogger) Warn(ctx context.Context, msg string, fields ...Field) {
+	z.log(ctx, WarnLevel, msg, fields)
+}
+
+// Error logs a message at ErrorLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+func (z *zapLogger) Error(ctx context.Context, msg string, fields ...Field) {
+	z.log(ctx, ErrorLevel, msg, fields)
+}
+
+// Panic logs a message at PanicLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+//
+// The logger then panics, even if logging at PanicLevel is disabled.
+func (z *zapLogger) Panic(ctx context.Context, msg string, fields ...Field) {
+	z.log(ctx, PanicLevel, msg, fields)
+}
+
+// Fatal logs a message at FatalLevel. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+//
+// The logger then calls os.Exit(1), even if logging at FatalLevel is
+// disabled.
+func (z *zapLogger) Fatal(ctx context.Context, msg string, fields ...Field) {
+	z.log(ctx, FatalLevel, msg, fields)
+}
+
+// Log logs a message at the specified level. The message includes any fields
+// passed at the log site, as well as any fields accumulated on the logger.
+func (z *zapLogger) Log(ctx context.Context, lvl Level, msg string, fields ...Field) {
+	z.log(ctx, lvl, msg, fields)
+}
+
+// log keeps the depth between the caller and zap the same for every entry
+// point, so the reported caller is the log site.
+func (z *zapLogger) log(ctx context.Context, lvl Level, msg string, fields []Field) {
+	if z.zap == nil {
+		return
+	}
+	// static extend fields
+	fields = append(fields, z.extfields...)
+
+	// context extend fields
+	cval := ctx.Value(ContextFieldsKey)
+	if cval != nil {
+		if cfields, ok := cval.([]Field); ok {
+			fields = append(fields, cfields...)
+		}
+	}
+	// write
+	if ce := z.zap.Check(lvl, msg); ce != nil {
+		if len(fields) == 0 {
+			ce.Write()
+			return
+		}
+		ce.Write(FieldsConvert(fields)...)
+	}
+}
+
+// Sync flushing any buffered log entries.
+//
+// Applications should take care to call Sync before exiting.
+func (z *zapLogger) Sync(ctx context.Context) {
+	if z.zap == nil {
+		return
+	}
+	z.zap.Sync()
+}
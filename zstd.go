@@ -0,0 +1,349 @@
+package log4go
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+const (
+	zstdMagic           = 0xfd2fb528
+	zstdBlockSize       = 128 << 10
+	zstdWindowLog       = 17
+	zstdTableBits       = 14
+	zstdMinMatch        = 4
+	zstdBlockRaw        = 0
+	zstdBlockCompressed = 2
+)
+
+// ZstdCompressor compresses with zstd. Matches are found within blocks of
+// 128 KiB, as by snappyEncode, and encoded with the predefined tables of
+// the format, while literals are stored as is: the files are larger than
+// with the reference encoder, but readable by any zstd decoder.
+type ZstdCompressor struct{}
+
+func (ZstdCompressor) Extension() string {
+	return ".zst"
+}
+
+func (ZstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return &zstdWriter{w: w}, nil
+}
+
+// zstdWriter writes a single zstd frame, a block per 128 KiB written.
+type zstdWriter struct {
+	w       io.Writer
+	buf     []byte
+	out     []byte
+	started bool
+	err     error
+}
+
+func (z *zstdWriter) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	z.buf = append(z.buf, p...)
+	for len(z.buf) > zstdBlockSize && z.err == nil {
+		z.err = z.writeBlock(z.buf[:zstdBlockSize], false)
+		z.buf = append(z.buf[:0], z.buf[zstdBlockSize:]...)
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+	return len(p), nil
+}
+
+// Close writes the last block, it doesn't close the underlying writer.
+func (z *zstdWriter) Close() error {
+	if z.err != nil {
+		return z.err
+	}
+	z.err = z.writeBlock(z.buf, true)
+	z.buf = nil
+	return z.err
+}
+
+func (z *zstdWriter) writeBlock(src []byte, last bool) error {
+	z.out = z.out[:0]
+	if !z.started {
+		// no content size nor checksum, window of zstdBlockSize
+		z.out = append(z.out, 0, 0, 0, 0, 0, (zstdWindowLog-10)<<3)
+		binary.LittleEndian.PutUint32(z.out, zstdMagic)
+		z.started = true
+	}
+	header := len(z.out)
+	z.out = append(z.out, 0, 0, 0)
+	z.out = zstdCompressBlock(z.out, src)
+	kind, size := zstdBlockCompressed, len(z.out)-header-3
+	if size >= len(src) {
+		z.out = append(z.out[:header+3], src...)
+		kind, size = zstdBlockRaw, len(src)
+	}
+	h := uint32(size)<<3 | uint32(kind)<<1
+	if last {
+		h |= 1
+	}
+	z.out[header], z.out[header+1], z.out[header+2] = byte(h), byte(h>>8), byte(h>>16)
+	_, err := z.w.Write(z.out)
+	return err
+}
+
+// zstdSequence copies litLen literals, then matchLen bytes from offset
+// bytes back.
+type zstdSequence struct {
+	litLen, matchLen, offset uint32
+}
+
+// zstdCompressBlock appends the literals and sequences sections of a
+// compressed block of src.
+func zstdCompressBlock(dst, src []byte) []byte {
+	var table [1 << zstdTableBits]int32
+	var seqs []zstdSequence
+	var lits []byte
+	lit := 0
+	for i := 0; i+zstdMinMatch <= len(src); {
+		cur := binary.LittleEndian.Uint32(src[i:])
+		h := (cur * 0x1e35a7bd) >> (32 - zstdTableBits)
+		cand := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if cand < 0 || binary.LittleEndian.Uint32(src[cand:]) != cur {
+			i++
+			continue
+		}
+		n := zstdMinMatch
+		for i+n < len(src) && src[cand+n] == src[i+n] {
+			n++
+		}
+		lits = append(lits, src[lit:i]...)
+		seqs = append(seqs, zstdSequence{litLen: uint32(i - lit), matchLen: uint32(n), offset: uint32(i - cand)})
+		i += n
+		lit = i
+	}
+	lits = append(lits, src[lit:]...)
+
+	// raw literals
+	switch n := len(lits); {
+	case n < 1<<5:
+		dst = append(dst, byte(n<<3))
+	case n < 1<<12:
+		dst = append(dst, byte(n<<4|1<<2), byte(n>>4))
+	default:
+		dst = append(dst, byte(n<<4|3<<2), byte(n>>4), byte(n>>12))
+	}
+	dst = append(dst, lits...)
+
+	switch n := len(seqs); {
+	case n < 0x80:
+		dst = append(dst, byte(n))
+	case n < 0x7f00:
+		dst = append(dst, byte(n>>8|0x80), byte(n))
+	default:
+		dst = append(dst, 0xff, byte(n-0x7f00), byte((n-0x7f00)>>8))
+	}
+	if len(seqs) == 0 {
+		return dst
+	}
+	// predefined modes for the three codes
+	dst = append(dst, 0)
+	return zstdEncodeSequences(dst, seqs)
+}
+
+// zstdEncodeSequences appends the bitstream of the sequences, written from
+// the last one as it is read backward.
+func zstdEncodeSequences(dst []byte, seqs []zstdSequence) []byte {
+	type code struct {
+		ll, ml, of                uint8
+		llBits, mlBits, ofBits    uint8
+		llExtra, mlExtra, ofExtra uint32
+	}
+	codes := make([]code, len(seqs))
+	for i, s := range seqs {
+		c := &codes[i]
+		c.ll = zstdLitLenCode(s.litLen)
+		c.llExtra, c.llBits = s.litLen-zstdLitLenBase[c.ll], zstdLitLenBits[c.ll]
+		c.ml = zstdMatchLenCode(s.matchLen)
+		c.mlExtra, c.mlBits = s.matchLen-zstdMatchLenBase[c.ml], zstdMatchLenBits[c.ml]
+		// offsets above 3, as the repeat offsets aren't used
+		value := s.offset + 3
+		c.of = uint8(bits.Len32(value) - 1)
+		c.ofExtra, c.ofBits = value-1<<c.of, c.of
+	}
+
+	w := zstdBitWriter{out: dst}
+	last := &codes[len(codes)-1]
+	ll := zstdLitLenTable.init(last.ll)
+	ml := zstdMatchLenTable.init(last.ml)
+	of := zstdOffsetTable.init(last.of)
+	w.add(last.llExtra, uint(last.llBits))
+	w.add(last.mlExtra, uint(last.mlBits))
+	w.add(last.ofExtra, uint(last.ofBits))
+	for i := len(codes) - 2; i >= 0; i-- {
+		c := &codes[i]
+		zstdOffsetTable.encode(&w, &of, c.of)
+		zstdMatchLenTable.encode(&w, &ml, c.ml)
+		zstdLitLenTable.encode(&w, &ll, c.ll)
+		w.add(c.llExtra, uint(c.llBits))
+		w.add(c.mlExtra, uint(c.mlBits))
+		w.add(c.ofExtra, uint(c.ofBits))
+	}
+	w.add(ml, zstdMatchLenTable.log)
+	w.add(of, zstdOffsetTable.log)
+	w.add(ll, zstdLitLenTable.log)
+	return w.close()
+}
+
+// zstdBitWriter writes the bits from the least significant one.
+type zstdBitWriter struct {
+	out []byte
+	acc uint64
+	n   uint
+}
+
+func (w *zstdBitWriter) add(v uint32, n uint) {
+	w.acc |= uint64(v&(1<<n-1)) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+// close ends the stream with a 1 bit, so that the reader finds its start.
+func (w *zstdBitWriter) close() []byte {
+	w.add(1, 1)
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+func zstdLitLenCode(n uint32) uint8 {
+	if n < 16 {
+		return uint8(n)
+	}
+	for c := len(zstdLitLenBase) - 1; ; c-- {
+		if n >= zstdLitLenBase[c] {
+			return uint8(c)
+		}
+	}
+}
+
+func zstdMatchLenCode(n uint32) uint8 {
+	if n < 35 {
+		return uint8(n - 3)
+	}
+	for c := len(zstdMatchLenBase) - 1; ; c-- {
+		if n >= zstdMatchLenBase[c] {
+			return uint8(c)
+		}
+	}
+}
+
+var (
+	zstdLitLenBase = []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536}
+	zstdLitLenBits = []uint8{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16}
+	zstdMatchLenBase = []uint32{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539}
+	zstdMatchLenBits = []uint8{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16}
+
+	// the predefined distributions of the codes
+	zstdLitLenTable = newZstdFSETable(6, []int16{4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1})
+	zstdMatchLenTable = newZstdFSETable(6, []int16{1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1})
+	zstdOffsetTable = newZstdFSETable(5, []int16{1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1})
+)
+
+// zstdFSETable encodes the symbols of a normalized distribution with
+// finite state entropy, as laid out by the reference encoder.
+type zstdFSETable struct {
+	log        uint
+	states     []uint16
+	deltaBits  []uint32
+	deltaState []int32
+}
+
+func newZstdFSETable(log uint, norm []int16) *zstdFSETable {
+	size := 1 << log
+	t := &zstdFSETable{
+		log:        log,
+		states:     make([]uint16, size),
+		deltaBits:  make([]uint32, len(norm)),
+		deltaState: make([]int32, len(norm)),
+	}
+
+	// spread the symbols over the states as the decoder does, those of
+	// probability "less than 1" at the end
+	symbols := make([]uint8, size)
+	cumul := make([]int, len(norm)+1)
+	high := size - 1
+	for s, n := range norm {
+		if n == -1 {
+			cumul[s+1] = cumul[s] + 1
+			symbols[high] = uint8(s)
+			high--
+		} else {
+			cumul[s+1] = cumul[s] + int(n)
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, n := range norm {
+		for i := 0; i < int(n); i++ {
+			symbols[pos] = uint8(s)
+			pos = (pos + step) & (size - 1)
+			for pos > high {
+				pos = (pos + step) & (size - 1)
+			}
+		}
+	}
+	for u, s := range symbols {
+		t.states[cumul[s]] = uint16(size + u)
+		cumul[s]++
+	}
+
+	total := 0
+	for s, n := range norm {
+		switch n {
+		case -1, 1:
+			t.deltaBits[s] = uint32(log<<16) - uint32(size)
+			t.deltaState[s] = int32(total - 1)
+			total++
+		default:
+			maxBits := log - uint(bits.Len32(uint32(n-1))-1)
+			t.deltaBits[s] = uint32(maxBits<<16) - uint32(n)<<maxBits
+			t.deltaState[s] = int32(total - int(n))
+			total += int(n)
+		}
+	}
+	return t
+}
+
+// init returns the first state, of symbol s.
+func (t *zstdFSETable) init(s uint8) uint32 {
+	n := (t.deltaBits[s] + 1<<15) >> 16
+	v := n<<16 - t.deltaBits[s]
+	return uint32(t.states[int32(v>>n)+t.deltaState[s]])
+}
+
+// encode writes the bits of the state, moving it to symbol s.
+func (t *zstdFSETable) encode(w *zstdBitWriter, state *uint32, s uint8) {
+	n := (*state + t.deltaBits[s]) >> 16
+	w.add(*state, uint(n))
+	*state = uint32(t.states[int32(*state>>n)+t.deltaState[s]])
+}
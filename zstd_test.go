@@ -0,0 +1,49 @@
+package log4go
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestZstdCompressor(t *testing.T) {
+	var src []byte
+	for i := 0; i < 5000; i++ {
+		src = append(src, fmt.Sprintf(`{"level":"info","msg":"request %d","status":%d}`+"\n", i, 200+i%3*100)...)
+	}
+	src = append(src, bytes.Repeat([]byte{'x'}, 200<<10)...)
+
+	var buf bytes.Buffer
+	w, err := ZstdCompressor{}.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(src); i += 1000 {
+		end := i + 1000
+		if end > len(src) {
+			end = len(src)
+		}
+		if _, err := w.Write(src[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte{0x28, 0xb5, 0x2f, 0xfd}) || buf.Len() > len(src)/4 {
+		t.Fatalf("unexpected frame of %d bytes", buf.Len())
+	}
+
+	// checked against the reference decoder when installed
+	path, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd not installed")
+	}
+	cmd := exec.Command(path, "-d", "-c")
+	cmd.Stdin = &buf
+	out, err := cmd.Output()
+	if err != nil || !bytes.Equal(out, src) {
+		t.Errorf("zstd decompressed %d bytes, want %d: %v", len(out), len(src), err)
+	}
+}